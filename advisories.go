@@ -0,0 +1,105 @@
+package erlang
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+//go:embed advisories.toml
+var advisoriesTOML string
+
+// Advisory is a known vulnerability in a range of OTP releases.
+type Advisory struct {
+	ID      string   `toml:"id"`
+	Summary string   `toml:"summary"`
+	Fixed   []string `toml:"fixed"`
+}
+
+// AdvisoryDatabase is the table of advisories shipped with the buildpack.
+type AdvisoryDatabase struct {
+	Advisories []Advisory `toml:"advisories"`
+}
+
+// LoadAdvisories parses the advisory table embedded in the buildpack.
+func LoadAdvisories() (AdvisoryDatabase, error) {
+	return ParseAdvisories(advisoriesTOML)
+}
+
+func ParseAdvisories(content string) (AdvisoryDatabase, error) {
+	var db AdvisoryDatabase
+	_, err := toml.Decode(content, &db)
+	if err != nil {
+		return AdvisoryDatabase{}, fmt.Errorf("failed to parse advisory database: %w", err)
+	}
+
+	for _, advisory := range db.Advisories {
+		for _, fixed := range advisory.Fixed {
+			_, err := parseVersion(fixed)
+			if err != nil {
+				return AdvisoryDatabase{}, fmt.Errorf("advisory %s has invalid fixed version %q", advisory.ID, fixed)
+			}
+		}
+	}
+
+	return db, nil
+}
+
+// Match returns the advisories that affect version. Versions that are not
+// plain OTP release numbers, such as branch names, never match.
+func (db AdvisoryDatabase) Match(version string) []Advisory {
+	ver, err := parseVersion(parseOTPVersion(version))
+	if err != nil {
+		return nil
+	}
+
+	var matches []Advisory
+	for _, advisory := range db.Advisories {
+		if advisory.affects(ver) {
+			matches = append(matches, advisory)
+		}
+	}
+	return matches
+}
+
+func (a Advisory) affects(ver []int) bool {
+	oldestLine := -1
+	for _, fixed := range a.Fixed {
+		fix, _ := parseVersion(fixed)
+
+		if fix[0] == ver[0] {
+			return compareVersions(ver, fix) < 0
+		}
+
+		if oldestLine == -1 || fix[0] < oldestLine {
+			oldestLine = fix[0]
+		}
+	}
+
+	return oldestLine != -1 && ver[0] < oldestLine
+}
+
+// FixedVersionFor returns the release that fixes the advisory for version:
+// the fix in the same major line, or the oldest fix in a newer line.
+func (a Advisory) FixedVersionFor(version string) string {
+	ver, err := parseVersion(parseOTPVersion(version))
+	if err != nil {
+		return strings.Join(a.Fixed, ", ")
+	}
+
+	var best []int
+	var bestText string
+	for _, fixed := range a.Fixed {
+		fix, _ := parseVersion(fixed)
+		if fix[0] == ver[0] {
+			return fixed
+		}
+		if fix[0] > ver[0] && (best == nil || compareVersions(fix, best) < 0) {
+			best, bestText = fix, fixed
+		}
+	}
+
+	return bestText
+}
@@ -0,0 +1,81 @@
+package erlang_test
+
+import (
+	"testing"
+
+	"github.com/SnakeDoc/erlang-cnb"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testAdvisories(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		db erlang.AdvisoryDatabase
+	)
+
+	it.Before(func() {
+		var err error
+		db, err = erlang.ParseAdvisories(`
+			[[advisories]]
+			id = "CVE-2025-32433"
+			summary = "SSH server allows unauthenticated remote code execution"
+			fixed = ["25.3.2.20", "26.2.5.11", "27.3.3"]
+		`)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	context("Match", func() {
+		it("matches versions older than the fix in their line", func() {
+			Expect(db.Match("27.3.2")).To(HaveLen(1))
+			Expect(db.Match("26.2.5.10")).To(HaveLen(1))
+			Expect(db.Match("OTP-25.3")).To(HaveLen(1))
+		})
+
+		it("matches lines older than every fixed line", func() {
+			Expect(db.Match("24.3.4.17")).To(HaveLen(1))
+		})
+
+		it("does not match fixed or newer versions", func() {
+			Expect(db.Match("27.3.3")).To(BeEmpty())
+			Expect(db.Match("26.2.5.11")).To(BeEmpty())
+			Expect(db.Match("28.0")).To(BeEmpty())
+		})
+
+		it("ignores versions that are not release numbers", func() {
+			Expect(db.Match("master")).To(BeEmpty())
+		})
+	})
+
+	context("FixedVersionFor", func() {
+		it("returns the fix in the same line", func() {
+			Expect(db.Advisories[0].FixedVersionFor("26.1")).To(Equal("26.2.5.11"))
+		})
+
+		it("returns the oldest fix in a newer line", func() {
+			Expect(db.Advisories[0].FixedVersionFor("24.3")).To(Equal("25.3.2.20"))
+		})
+	})
+
+	context("LoadAdvisories", func() {
+		it("loads the embedded database", func() {
+			embedded, err := erlang.LoadAdvisories()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(embedded.Advisories).NotTo(BeEmpty())
+			Expect(embedded.Match("27.3.2")).NotTo(BeEmpty())
+		})
+	})
+
+	context("failure cases", func() {
+		it("rejects invalid fixed versions", func() {
+			_, err := erlang.ParseAdvisories(`
+				[[advisories]]
+				id = "CVE-1"
+				fixed = ["latest"]
+			`)
+			Expect(err).To(MatchError(`advisory CVE-1 has invalid fixed version "latest"`))
+		})
+	})
+}
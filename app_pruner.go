@@ -0,0 +1,225 @@
+package erlang
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// coreApps are needed to boot any Erlang node and are never pruned.
+var coreApps = []string{"kernel", "stdlib"}
+
+// AppSelection lists the OTP applications requested through
+// BP_ERLANG_INCLUDE_APPS and BP_ERLANG_EXCLUDE_APPS.
+type AppSelection struct {
+	Include []string
+	Exclude []string
+}
+
+func ParseAppSelection() AppSelection {
+	return AppSelection{
+		Include: splitList(os.Getenv("BP_ERLANG_INCLUDE_APPS")),
+		Exclude: splitList(os.Getenv("BP_ERLANG_EXCLUDE_APPS")),
+	}
+}
+
+func (s AppSelection) IsEmpty() bool {
+	return len(s.Include) == 0 && len(s.Exclude) == 0
+}
+
+// OTPApp describes an application found under lib/ in an OTP installation.
+type OTPApp struct {
+	Name    string
+	Version string
+	Dir     string
+
+	// Requires holds the applications that must be present for this one to
+	// start: its applications minus optional_applications, plus
+	// included_applications.
+	Requires []string
+}
+
+// ReadOTPApps parses lib/*/ebin/<app>.app under layerPath.
+func ReadOTPApps(layerPath string) (map[string]OTPApp, error) {
+	dirs, err := filepath.Glob(filepath.Join(layerPath, "lib", "*-*"))
+	if err != nil {
+		return nil, err
+	}
+
+	apps := map[string]OTPApp{}
+	for _, dir := range dirs {
+		base := filepath.Base(dir)
+		idx := strings.LastIndex(base, "-")
+		name, version := base[:idx], base[idx+1:]
+
+		appFile := filepath.Join(dir, "ebin", name+".app")
+		content, err := os.ReadFile(appFile)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		app, err := parseAppFile(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", appFile, err)
+		}
+
+		app.Version = version
+		app.Dir = dir
+		apps[name] = app
+	}
+
+	return apps, nil
+}
+
+func parseAppFile(content string) (OTPApp, error) {
+	terms, err := ParseTerms(content)
+	if err != nil {
+		return OTPApp{}, err
+	}
+
+	if len(terms) != 1 {
+		return OTPApp{}, fmt.Errorf("expected a single application term")
+	}
+
+	tuple, ok := terms[0].(Tuple)
+	if !ok || len(tuple) != 3 || tuple[0] != Atom("application") {
+		return OTPApp{}, fmt.Errorf("expected {application, Name, Properties}")
+	}
+
+	name, _ := tuple[1].(Atom)
+	props, _ := tuple[2].(List)
+
+	applications, _ := proplistValue(props, "applications")
+	optional, _ := proplistValue(props, "optional_applications")
+	included, _ := proplistValue(props, "included_applications")
+
+	isOptional := map[string]bool{}
+	for _, app := range atomList(optional) {
+		isOptional[app] = true
+	}
+
+	app := OTPApp{Name: string(name)}
+	for _, dep := range atomList(applications) {
+		if !isOptional[dep] {
+			app.Requires = append(app.Requires, dep)
+		}
+	}
+	app.Requires = append(app.Requires, atomList(included)...)
+
+	return app, nil
+}
+
+// SelectApplications returns the names of the applications in apps that are
+// kept by selection. When Include is set only those applications, the core
+// applications and their transitive dependencies are kept. Excluding an
+// application that a kept application depends on is an error.
+func SelectApplications(apps map[string]OTPApp, selection AppSelection) (map[string]bool, error) {
+	for _, name := range append(append([]string{}, selection.Include...), selection.Exclude...) {
+		if _, ok := apps[name]; !ok {
+			return nil, fmt.Errorf("unknown OTP application %q", name)
+		}
+	}
+
+	excluded := map[string]bool{}
+	for _, name := range selection.Exclude {
+		excluded[name] = true
+	}
+
+	for _, name := range selection.Include {
+		if excluded[name] {
+			return nil, fmt.Errorf("cannot both include and exclude %s: it is listed in BP_ERLANG_INCLUDE_APPS and BP_ERLANG_EXCLUDE_APPS", name)
+		}
+	}
+
+	for _, name := range coreApps {
+		if excluded[name] {
+			return nil, fmt.Errorf("cannot exclude %s: it is required to boot the runtime", name)
+		}
+	}
+
+	var roots []string
+	if len(selection.Include) > 0 {
+		roots = append(append(roots, coreApps...), selection.Include...)
+	} else {
+		for name := range apps {
+			if !excluded[name] {
+				roots = append(roots, name)
+			}
+		}
+	}
+	sort.Strings(roots)
+
+	// walk the dependency graph, remembering which application first pulled
+	// each one in so that a conflicting exclusion can be explained
+	keep := map[string]bool{}
+	requiredBy := map[string]string{}
+	queue := roots
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+
+		if keep[name] {
+			continue
+		}
+
+		if excluded[name] {
+			return nil, fmt.Errorf("cannot exclude %s: it is required by %s", name, requiredBy[name])
+		}
+
+		if _, ok := apps[name]; !ok {
+			continue
+		}
+		keep[name] = true
+
+		for _, dep := range apps[name].Requires {
+			if _, seen := requiredBy[dep]; !seen {
+				requiredBy[dep] = name
+			}
+			queue = append(queue, dep)
+		}
+	}
+
+	return keep, nil
+}
+
+// PruneApplications removes the lib/<app>-<vsn> directories from layerPath
+// that are not kept by selection, as decided by SelectApplications. The names
+// of the removed applications are returned in sorted order.
+func PruneApplications(layerPath string, selection AppSelection) ([]string, error) {
+	apps, err := ReadOTPApps(layerPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OTP applications: %w", err)
+	}
+
+	keep, err := SelectApplications(apps, selection)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for name, app := range apps {
+		if keep[name] {
+			continue
+		}
+
+		err = os.RemoveAll(app.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to remove %s: %w", app.Dir, err)
+		}
+		removed = append(removed, name)
+	}
+	sort.Strings(removed)
+
+	return removed, nil
+}
+
+func splitList(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	})
+}
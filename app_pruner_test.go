@@ -0,0 +1,151 @@
+package erlang_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SnakeDoc/erlang-cnb"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testAppPruner(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerPath string
+	)
+
+	writeOTPApp := func(layerPath, name, version, props string) {
+		ebin := filepath.Join(layerPath, "lib", fmt.Sprintf("%s-%s", name, version), "ebin")
+		Expect(os.MkdirAll(ebin, os.ModePerm)).To(Succeed())
+
+		content := fmt.Sprintf("{application, %s, [{vsn, %q}%s]}.\n", name, version, props)
+		Expect(os.WriteFile(filepath.Join(ebin, name+".app"), []byte(content), 0644)).To(Succeed())
+	}
+
+	it.Before(func() {
+		var err error
+		layerPath, err = os.MkdirTemp("", "layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		writeOTPApp(layerPath, "kernel", "10.4", "")
+		writeOTPApp(layerPath, "stdlib", "7.1", ", {applications, [kernel]}")
+		writeOTPApp(layerPath, "crypto", "5.7", ", {applications, [kernel, stdlib]}")
+		writeOTPApp(layerPath, "public_key", "1.18", ", {applications, [asn1, crypto, kernel, stdlib]}")
+		writeOTPApp(layerPath, "asn1", "5.4", ", {applications, [kernel, stdlib]}")
+		writeOTPApp(layerPath, "ssl", "11.4", ", {applications, [crypto, public_key, kernel, stdlib]}")
+		writeOTPApp(layerPath, "wx", "2.5", ", {applications, [kernel, stdlib]}")
+		writeOTPApp(layerPath, "observer", "2.18", ", {applications, [kernel, stdlib, wx]}, {optional_applications, [wx]}")
+		writeOTPApp(layerPath, "et", "1.7", ", {applications, [kernel, stdlib, wx]}")
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layerPath)).To(Succeed())
+		Expect(os.Unsetenv("BP_ERLANG_INCLUDE_APPS")).To(Succeed())
+		Expect(os.Unsetenv("BP_ERLANG_EXCLUDE_APPS")).To(Succeed())
+	})
+
+	context("ParseAppSelection", func() {
+		it("reads comma or space separated lists from the environment", func() {
+			Expect(os.Setenv("BP_ERLANG_INCLUDE_APPS", "ssl, crypto")).To(Succeed())
+			Expect(os.Setenv("BP_ERLANG_EXCLUDE_APPS", "wx observer")).To(Succeed())
+
+			Expect(erlang.ParseAppSelection()).To(Equal(erlang.AppSelection{
+				Include: []string{"ssl", "crypto"},
+				Exclude: []string{"wx", "observer"},
+			}))
+		})
+	})
+
+	context("ReadOTPApps", func() {
+		it("reads versions and required applications", func() {
+			apps, err := erlang.ReadOTPApps(layerPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(apps).To(HaveLen(9))
+
+			Expect(apps["ssl"].Version).To(Equal("11.4"))
+			Expect(apps["ssl"].Requires).To(Equal([]string{"crypto", "public_key", "kernel", "stdlib"}))
+			Expect(apps["observer"].Requires).To(Equal([]string{"kernel", "stdlib"}))
+		})
+	})
+
+	context("PruneApplications", func() {
+		it("removes excluded applications", func() {
+			removed, err := erlang.PruneApplications(layerPath, erlang.AppSelection{
+				Exclude: []string{"et", "observer", "wx"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(removed).To(Equal([]string{"et", "observer", "wx"}))
+
+			Expect(filepath.Join(layerPath, "lib", "wx-2.5")).NotTo(BeAnExistingFile())
+			Expect(filepath.Join(layerPath, "lib", "ssl-11.4")).To(BeADirectory())
+		})
+
+		it("keeps the dependency closure of included applications", func() {
+			removed, err := erlang.PruneApplications(layerPath, erlang.AppSelection{
+				Include: []string{"ssl"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(removed).To(Equal([]string{"et", "observer", "wx"}))
+
+			Expect(filepath.Join(layerPath, "lib", "asn1-5.4")).To(BeADirectory())
+			Expect(filepath.Join(layerPath, "lib", "kernel-10.4")).To(BeADirectory())
+		})
+
+		it("allows excluding an optional dependency", func() {
+			removed, err := erlang.PruneApplications(layerPath, erlang.AppSelection{
+				Include: []string{"observer"},
+				Exclude: []string{"wx"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(removed).To(ContainElement("wx"))
+			Expect(removed).NotTo(ContainElement("observer"))
+		})
+
+		context("failure cases", func() {
+			it("rejects an exclusion that breaks a kept application", func() {
+				_, err := erlang.PruneApplications(layerPath, erlang.AppSelection{
+					Exclude: []string{"asn1"},
+				})
+				Expect(err).To(MatchError("cannot exclude asn1: it is required by public_key"))
+				Expect(filepath.Join(layerPath, "lib", "asn1-5.4")).To(BeADirectory())
+			})
+
+			it("rejects an application that is both included and excluded", func() {
+				_, err := erlang.PruneApplications(layerPath, erlang.AppSelection{
+					Include: []string{"ssl"},
+					Exclude: []string{"ssl"},
+				})
+				Expect(err).To(MatchError("cannot both include and exclude ssl: it is listed in BP_ERLANG_INCLUDE_APPS and BP_ERLANG_EXCLUDE_APPS"))
+			})
+
+			it("rejects excluding a core application", func() {
+				_, err := erlang.PruneApplications(layerPath, erlang.AppSelection{
+					Exclude: []string{"stdlib"},
+				})
+				Expect(err).To(MatchError("cannot exclude stdlib: it is required to boot the runtime"))
+			})
+
+			it("rejects unknown applications", func() {
+				_, err := erlang.PruneApplications(layerPath, erlang.AppSelection{
+					Include: []string{"nope"},
+				})
+				Expect(err).To(MatchError(`unknown OTP application "nope"`))
+			})
+
+			it("reports malformed .app files", func() {
+				appFile := filepath.Join(layerPath, "lib", "wx-2.5", "ebin", "wx.app")
+				Expect(os.WriteFile(appFile, []byte("{application, wx"), 0644)).To(Succeed())
+
+				_, err := erlang.PruneApplications(layerPath, erlang.AppSelection{
+					Exclude: []string{"wx"},
+				})
+				Expect(err).To(MatchError(ContainSubstring("failed to parse")))
+			})
+		})
+	})
+}
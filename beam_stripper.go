@@ -0,0 +1,211 @@
+package erlang
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// loaderChunks are the BEAM chunks kept when stripping. They are the chunks
+// the code loader reads, plus Attr and CInf so that module_info/1 still
+// reports attributes and compile options.
+var loaderChunks = map[string]bool{
+	"AtU8": true,
+	"Atom": true,
+	"Code": true,
+	"StrT": true,
+	"ImpT": true,
+	"ExpT": true,
+	"FunT": true,
+	"LitT": true,
+	"Line": true,
+	"Type": true,
+	"Meta": true,
+	"Attr": true,
+	"CInf": true,
+}
+
+// requiredChunks must be present in every stripped module.
+var requiredChunks = []string{"Code", "ImpT", "ExpT"}
+
+type beamChunk struct {
+	ID   string
+	Data []byte
+}
+
+// BeamStripStats summarises a StripBeams run.
+type BeamStripStats struct {
+	Files      int
+	SavedBytes int64
+}
+
+// StripBeams rewrites every uncompressed .beam file under dir so that it only
+// contains the chunks needed at runtime, dropping Dbgi, Docs, ExCk and the
+// like.
+func StripBeams(dir string) (BeamStripStats, error) {
+	var stats BeamStripStats
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() || !strings.HasSuffix(d.Name(), ".beam") {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		// compressed modules are loaded as-is
+		if bytes.HasPrefix(content, []byte{0x1f, 0x8b}) {
+			return nil
+		}
+
+		stripped, err := StripBeam(content)
+		if err != nil {
+			return fmt.Errorf("failed to strip %s: %w", path, err)
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		err = os.WriteFile(path, stripped, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+
+		stats.Files++
+		stats.SavedBytes += int64(len(content) - len(stripped))
+
+		return nil
+	})
+	if err != nil {
+		return BeamStripStats{}, err
+	}
+
+	return stats, nil
+}
+
+// StripBeam returns a copy of the BEAM module in content containing only the
+// loader chunks. The result is parsed again and checked against the original
+// so that a damaged module is reported rather than shipped.
+func StripBeam(content []byte) ([]byte, error) {
+	chunks, err := readBeamChunks(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var kept []beamChunk
+	for _, chunk := range chunks {
+		if loaderChunks[chunk.ID] {
+			kept = append(kept, chunk)
+		}
+	}
+
+	stripped := writeBeamChunks(kept)
+
+	err = verifyStrippedBeam(stripped, kept)
+	if err != nil {
+		return nil, err
+	}
+
+	return stripped, nil
+}
+
+func readBeamChunks(content []byte) ([]beamChunk, error) {
+	if len(content) < 12 || string(content[0:4]) != "FOR1" || string(content[8:12]) != "BEAM" {
+		return nil, fmt.Errorf("not a BEAM file")
+	}
+
+	size := int(binary.BigEndian.Uint32(content[4:8]))
+	if size+8 > len(content) {
+		return nil, fmt.Errorf("BEAM header declares %d bytes, file has %d", size+8, len(content))
+	}
+
+	var chunks []beamChunk
+	pos := 12
+	end := size + 8
+	for pos < end {
+		if pos+8 > end {
+			return nil, fmt.Errorf("truncated chunk header at offset %d", pos)
+		}
+
+		id := string(content[pos : pos+4])
+		length := int(binary.BigEndian.Uint32(content[pos+4 : pos+8]))
+		pos += 8
+
+		if pos+length > end {
+			return nil, fmt.Errorf("chunk %s at offset %d overruns the file", id, pos-8)
+		}
+
+		chunks = append(chunks, beamChunk{ID: id, Data: content[pos : pos+length]})
+		pos += align4(length)
+	}
+
+	return chunks, nil
+}
+
+func writeBeamChunks(chunks []beamChunk) []byte {
+	var body bytes.Buffer
+	body.WriteString("BEAM")
+
+	for _, chunk := range chunks {
+		body.WriteString(chunk.ID)
+		binary.Write(&body, binary.BigEndian, uint32(len(chunk.Data)))
+		body.Write(chunk.Data)
+		body.Write(make([]byte, align4(len(chunk.Data))-len(chunk.Data)))
+	}
+
+	var out bytes.Buffer
+	out.WriteString("FOR1")
+	binary.Write(&out, binary.BigEndian, uint32(body.Len()))
+	out.Write(body.Bytes())
+
+	return out.Bytes()
+}
+
+func verifyStrippedBeam(stripped []byte, expected []beamChunk) error {
+	loaded, err := readBeamChunks(stripped)
+	if err != nil {
+		return fmt.Errorf("stripped module is unreadable: %w", err)
+	}
+
+	if len(loaded) != len(expected) {
+		return fmt.Errorf("stripped module has %d chunks, expected %d", len(loaded), len(expected))
+	}
+
+	present := map[string]bool{}
+	for i, chunk := range loaded {
+		if chunk.ID != expected[i].ID || !bytes.Equal(chunk.Data, expected[i].Data) {
+			return fmt.Errorf("chunk %s does not match the original module", expected[i].ID)
+		}
+		if !loaderChunks[chunk.ID] {
+			return fmt.Errorf("unexpected chunk %s in stripped module", chunk.ID)
+		}
+		present[chunk.ID] = true
+	}
+
+	if !present["AtU8"] && !present["Atom"] {
+		return fmt.Errorf("stripped module has no atom table")
+	}
+
+	for _, id := range requiredChunks {
+		if !present[id] {
+			return fmt.Errorf("stripped module is missing the %s chunk", id)
+		}
+	}
+
+	return nil
+}
+
+func align4(n int) int {
+	return (n + 3) &^ 3
+}
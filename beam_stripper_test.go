@@ -0,0 +1,121 @@
+package erlang_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SnakeDoc/erlang-cnb"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+// buildBeam assembles a BEAM container from id/data pairs.
+func buildBeam(chunks ...string) []byte {
+	var body bytes.Buffer
+	body.WriteString("BEAM")
+	for i := 0; i < len(chunks); i += 2 {
+		body.WriteString(chunks[i])
+		binary.Write(&body, binary.BigEndian, uint32(len(chunks[i+1])))
+		body.WriteString(chunks[i+1])
+		for body.Len()%4 != 0 {
+			body.WriteByte(0)
+		}
+	}
+
+	var out bytes.Buffer
+	out.WriteString("FOR1")
+	binary.Write(&out, binary.BigEndian, uint32(body.Len()))
+	out.Write(body.Bytes())
+	return out.Bytes()
+}
+
+func testBeamStripper(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("StripBeam", func() {
+		it("keeps only the loader chunks", func() {
+			original := buildBeam(
+				"AtU8", "atoms",
+				"Code", "code!",
+				"ImpT", "imp",
+				"ExpT", "exp",
+				"Dbgi", "lots of debug info",
+				"Docs", "documentation",
+				"ExCk", "elixir checker",
+				"Attr", "attributes",
+			)
+
+			stripped, err := erlang.StripBeam(original)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stripped).To(Equal(buildBeam(
+				"AtU8", "atoms",
+				"Code", "code!",
+				"ImpT", "imp",
+				"ExpT", "exp",
+				"Attr", "attributes",
+			)))
+		})
+
+		context("failure cases", func() {
+			it("rejects files that are not BEAM containers", func() {
+				_, err := erlang.StripBeam([]byte("not a beam file"))
+				Expect(err).To(MatchError("not a BEAM file"))
+			})
+
+			it("rejects truncated chunks", func() {
+				original := buildBeam("AtU8", "atoms", "Code", "code")
+				binary.BigEndian.PutUint32(original[32:36], 1000)
+
+				_, err := erlang.StripBeam(original)
+				Expect(err).To(MatchError(ContainSubstring("overruns the file")))
+			})
+
+			it("rejects modules missing a chunk the loader needs", func() {
+				_, err := erlang.StripBeam(buildBeam("AtU8", "atoms", "ImpT", "imp", "ExpT", "exp"))
+				Expect(err).To(MatchError("stripped module is missing the Code chunk"))
+			})
+
+			it("rejects modules without an atom table", func() {
+				_, err := erlang.StripBeam(buildBeam("Code", "code", "ImpT", "imp", "ExpT", "exp"))
+				Expect(err).To(MatchError("stripped module has no atom table"))
+			})
+		})
+	})
+
+	context("StripBeams", func() {
+		var dir string
+
+		it.Before(func() {
+			var err error
+			dir, err = os.MkdirTemp("", "beams")
+			Expect(err).NotTo(HaveOccurred())
+
+			ebin := filepath.Join(dir, "lib", "stdlib-7.1", "ebin")
+			Expect(os.MkdirAll(ebin, os.ModePerm)).To(Succeed())
+
+			Expect(os.WriteFile(filepath.Join(ebin, "lists.beam"), buildBeam(
+				"AtU8", "atoms", "Code", "code", "ImpT", "imp", "ExpT", "exp", "Dbgi", "0123456789abcdef",
+			), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ebin, "compressed.beam"), []byte{0x1f, 0x8b, 0x08}, 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ebin, "stdlib.app"), []byte("{application, stdlib, []}."), 0644)).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(dir)).To(Succeed())
+		})
+
+		it("strips every uncompressed module and reports the savings", func() {
+			stats, err := erlang.StripBeams(dir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stats).To(Equal(erlang.BeamStripStats{Files: 1, SavedBytes: 24}))
+
+			content, err := os.ReadFile(filepath.Join(dir, "lib", "stdlib-7.1", "ebin", "lists.beam"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(content).To(Equal(buildBeam("AtU8", "atoms", "Code", "code", "ImpT", "imp", "ExpT", "exp")))
+		})
+	})
+}
@@ -1,32 +1,52 @@
 package erlang
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/paketo-buildpacks/packit/v2"
 	"github.com/paketo-buildpacks/packit/v2/chronos"
+	"github.com/paketo-buildpacks/packit/v2/fs"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
 	"github.com/paketo-buildpacks/packit/v2/scribe"
 )
 
 const (
 	Erlang           = "erlang"
 	LayerName        = "erlang"
+	LaunchLayerName  = "erlang-launch"
 	VersionKey       = "version"
 	ArchKey          = "arch"
 	UbuntuVersionKey = "ubuntu-version"
+	LayoutVersionKey = "layout-version"
+	IncludeAppsKey   = "include-apps"
+	ExcludeAppsKey   = "exclude-apps"
+	StripBeamsKey    = "strip-beams"
+	SHA256Key        = "sha256"
+
+	SourceDateEpochKey = "source-date-epoch"
+	RebarLockSHA256Key = "rebar-lock-sha256"
 )
 
+// LayoutVersion identifies how this buildpack assembles the erlang layer. It
+// must be bumped whenever that changes (environment variables, pruning,
+// relocation, ...) so that layers cached by an older release are rebuilt.
+const LayoutVersion = "5"
+
 //go:generate faux --interface Installer --output fakes/installer.go
 type Installer interface {
 	BuildDownloadURL(arch, ubuntuVersion, version string) string
-	Install(url, layerPath string) error
+	Install(url, layerPath string) (sha256 string, err error)
 }
 
-func Build(installer Installer, logger scribe.Emitter, clock chronos.Clock) packit.BuildFunc {
+func Build(installer Installer, rebar3Installer Rebar3Installer, rebar3 Executable, gnuMake Executable, erl Executable, erlc Executable, versionLister VersionLister, bindingResolver BindingResolver, logger scribe.Emitter, clock chronos.Clock) packit.BuildFunc {
 	return func(context packit.BuildContext) (packit.BuildResult, error) {
 		logger.Title("%s %s", context.BuildpackInfo.Name, context.BuildpackInfo.Version)
 
@@ -42,14 +62,119 @@ func Build(installer Installer, logger scribe.Emitter, clock chronos.Clock) pack
 		logger.Subprocess("Stack: %s (%s)", context.Stack, ubuntuVersion)
 
 		// resolve which version to install
-		version, err := ResolveVersion(arch, ubuntuVersion)
+		version, index, err := ResolveVersion(arch, ubuntuVersion)
 		if err != nil {
 			return packit.BuildResult{}, fmt.Errorf("failed to resolve Erlang version: %w", err)
 		}
 
+		// ResolveVersion only takes a pin from BP_ERLANG_VERSION; otherwise
+		// the version is the latest one in builds.txt
+		versionSource := "builds.txt"
+		if os.Getenv("BP_ERLANG_VERSION") != "" {
+			versionSource = "BP_ERLANG_VERSION"
+		}
+
 		logger.Action("Using Erlang version: %s", version)
 		logger.Break()
 
+		// a version pinned in .tool-versions is not installed, but it is still
+		// checked against the support calendar below
+		pinnedVersion, pinSource := "", ""
+		if versionSource == "BP_ERLANG_VERSION" {
+			pinnedVersion, pinSource = version, versionSource
+		} else if pin := toolVersionsPin(context.Plan); pin != "" {
+			pinnedVersion, pinSource = pin, ".tool-versions"
+			if pin != version {
+				logger.Process("WARNING: ignoring Erlang %s pinned in .tool-versions", pin)
+				logger.Subprocess("Only BP_ERLANG_VERSION selects the installed version; installing %s", version)
+				logger.Break()
+			}
+		}
+
+		// available releases are only needed to suggest alternatives, so they
+		// are listed lazily and at most once
+		var available []string
+		var listErr error
+		listed := false
+		listVersions := func() []string {
+			if !listed {
+				available, listErr = versionLister.ListVersions(arch, ubuntuVersion)
+				if listErr != nil {
+					logger.Subprocess("Unable to list available Erlang versions: %s", listErr)
+				}
+				listed = true
+			}
+			return available
+		}
+
+		advisories, err := LoadAdvisories()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		if matches := advisories.Match(version); len(matches) > 0 {
+			var ids []string
+			logger.Process("WARNING: Erlang %s is affected by known vulnerabilities", version)
+			for _, advisory := range matches {
+				ids = append(ids, advisory.ID)
+				logger.Subprocess("%s: %s (fixed in %s)", advisory.ID, advisory.Summary, advisory.FixedVersionFor(version))
+			}
+			logger.Break()
+
+			if os.Getenv("BP_ERLANG_FAIL_ON_VULNERABLE") == "true" {
+				return packit.BuildResult{}, fmt.Errorf("refusing to install Erlang %s: affected by %s and BP_ERLANG_FAIL_ON_VULNERABLE is set", version, strings.Join(ids, ", "))
+			}
+		}
+
+		epoch, _, err := SourceDateEpoch()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+		epochKey := strconv.FormatInt(epoch.Unix(), 10)
+
+		policy, err := LoadVersionPolicy(bindingResolver, context.Platform.Path)
+		if err != nil {
+			return packit.BuildResult{}, fmt.Errorf("failed to load version policy: %w", err)
+		}
+
+		if !policy.Permits(version) {
+			// the suggestion is best effort; the policy is enforced either way
+			return packit.BuildResult{}, policy.Check(version, listVersions())
+		}
+
+		if pinnedVersion != "" {
+			calendar, err := LoadSupportCalendar()
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			now := clock.Now()
+			if status, ok := calendar.Status(pinnedVersion, now); ok && !status.Supported {
+				logger.Process("WARNING: Erlang %s is past end of support", pinnedVersion)
+				logger.Subprocess("Pinned through %s", pinSource)
+				if status.ReplacedBy != 0 {
+					logger.Subprocess("OTP %d stopped receiving updates on %s, when OTP %d was released", status.Major, status.EndOfSupport.Format(time.DateOnly), status.ReplacedBy)
+				} else {
+					logger.Subprocess("OTP %d is no longer maintained", status.Major)
+				}
+
+				newest := newestVersion(listVersions(), func(v string) bool {
+					return calendar.IsSupported(v, now) && policy.Permits(v)
+				})
+				if newest != "" {
+					logger.Subprocess("Newest supported patch: %s", newest)
+				}
+				logger.Break()
+
+				if os.Getenv("BP_ERLANG_FAIL_ON_UNSUPPORTED") == "true" {
+					if pinnedVersion != version {
+						return packit.BuildResult{}, fmt.Errorf("refusing to build with Erlang %s pinned in %s: OTP %d is past end of support and BP_ERLANG_FAIL_ON_UNSUPPORTED is set", pinnedVersion, pinSource, status.Major)
+					}
+					return packit.BuildResult{}, fmt.Errorf("refusing to install Erlang %s: OTP %d is past end of support and BP_ERLANG_FAIL_ON_UNSUPPORTED is set", version, status.Major)
+				}
+			}
+		}
+
 		// get or create the erlang layer
 		erlangLayer, err := context.Layers.Get(LayerName)
 		if err != nil {
@@ -60,65 +185,1099 @@ func Build(installer Installer, logger scribe.Emitter, clock chronos.Clock) pack
 		cachedVersion, _ := erlangLayer.Metadata[VersionKey].(string)
 		cachedArch, _ := erlangLayer.Metadata[ArchKey].(string)
 		cachedUbuntuVersion, _ := erlangLayer.Metadata[UbuntuVersionKey].(string)
+		cachedLayoutVersion, _ := erlangLayer.Metadata[LayoutVersionKey].(string)
+		cachedEpoch, _ := erlangLayer.Metadata[SourceDateEpochKey].(string)
+
+		downloadURL := installer.BuildDownloadURL(arch, ubuntuVersion, version)
+
+		reused := false
+		if cachedVersion == version && cachedArch == arch && cachedUbuntuVersion == ubuntuVersion && cachedEpoch == epochKey {
+			err = verifyCachedLayer(erlangLayer, cachedLayoutVersion)
+			if err == nil {
+				logger.Process("Reusing cached layer %s", erlangLayer.Path)
+				logger.Break()
+				reused = true
+			} else {
+				logger.Process("Rejecting cached layer %s: %s", erlangLayer.Path, err)
+				logger.Break()
+			}
+		}
+
+		if !reused {
+			// need to install - reset the layer
+			logger.Process("Executing build process")
+
+			erlangLayer, err = erlangLayer.Reset()
+			if err != nil {
+				return packit.BuildResult{}, fmt.Errorf("failed to reset %s layer: %w", LayerName, err)
+			}
+
+			// install erlang
+			logger.Subprocess("Downloading Erlang %s", version)
+			logger.Action("Source: %s", downloadURL)
+
+			var sha256 string
+			duration, err := clock.Measure(func() error {
+				var err error
+				sha256, err = installer.Install(downloadURL, erlangLayer.Path)
+				return err
+			})
+			if err != nil {
+				return packit.BuildResult{}, fmt.Errorf("failed to install Erlang %s: %w", version, err)
+			}
 
-		if cachedVersion == version && cachedArch == arch && cachedUbuntuVersion == ubuntuVersion {
-			logger.Process("Reusing cached layer %s", erlangLayer.Path)
+			logger.Action("Completed in %s", duration.Round(time.Millisecond).String())
 			logger.Break()
 
-			erlangLayer.Launch = true
-			erlangLayer.Cache = true
-			erlangLayer.Build = true
+			err = WriteProvenance(filepath.Join(erlangLayer.Path, ProvenanceFile), Provenance{
+				Version:          version,
+				VersionSource:    versionSource,
+				Arch:             arch,
+				UbuntuVersion:    ubuntuVersion,
+				Index:            index,
+				TarballURL:       downloadURL,
+				TarballSHA256:    sha256,
+				BuiltAt:          epoch,
+				BuildpackID:      context.BuildpackInfo.ID,
+				BuildpackVersion: context.BuildpackInfo.Version,
+			})
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
 
-			return packit.BuildResult{
-				Layers: []packit.Layer{erlangLayer},
-			}, nil
+			// setup env variables
+			erlangLayer.BuildEnv.Default("ERLANG_HOME", erlangLayer.Path)
+			erlangLayer.BuildEnv.Prepend("PATH", filepath.Join(erlangLayer.Path, "bin"), ":")
+
+			err = NormalizeLayer(erlangLayer.Path, epoch)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			manifest, err := ComputeLayerManifest(erlangLayer.Path)
+			if err != nil {
+				return packit.BuildResult{}, fmt.Errorf("failed to compute %s layer manifest: %w", LayerName, err)
+			}
+
+			// store metadata for caching
+			erlangLayer.Metadata = map[string]any{
+				VersionKey:       version,
+				ArchKey:          arch,
+				UbuntuVersionKey: ubuntuVersion,
+				LayoutVersionKey: LayoutVersion,
+				FileCountKey:     manifest.FileCount,
+				ChecksumKey:      manifest.Checksum,
+				SHA256Key:        sha256,
+
+				SourceDateEpochKey: epochKey,
+			}
+
+			logger.EnvironmentVariables(erlangLayer)
 		}
 
-		// need to install - reset the layer
-		logger.Process("Executing build process")
+		erlangLayer.Cache = true
+		erlangLayer.Build = true
+
+		apps := ParseAppSelection()
 
-		erlangLayer, err = erlangLayer.Reset()
+		otpApps, err := ReadOTPApps(erlangLayer.Path)
 		if err != nil {
-			return packit.BuildResult{}, fmt.Errorf("failed to reset %s layer: %w", LayerName, err)
+			return packit.BuildResult{}, fmt.Errorf("failed to read OTP applications: %w", err)
 		}
 
-		// install erlang
-		downloadURL := installer.BuildDownloadURL(arch, ubuntuVersion, version)
+		keptApps, err := SelectApplications(otpApps, apps)
+		if err != nil {
+			return packit.BuildResult{}, fmt.Errorf("failed to prune OTP applications: %w", err)
+		}
 
-		logger.Subprocess("Downloading Erlang %s", version)
-		logger.Action("Source: %s", downloadURL)
+		release := OTPRelease{Version: version, URL: downloadURL}
+		release.SHA256, _ = erlangLayer.Metadata[SHA256Key].(string)
 
-		duration, err := clock.Measure(func() error {
-			return installer.Install(downloadURL, erlangLayer.Path)
-		})
+		erlangLayer.SBOM, err = formatSBOM(erlangLayer.Path, release, appsIn(otpApps, nil), context.BuildpackInfo.SBOMFormats)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		// get or create the launch layer
+		launchLayer, err := context.Layers.Get(LaunchLayerName)
+		if err != nil {
+			return packit.BuildResult{}, fmt.Errorf("failed to get %s layer: %w", LaunchLayerName, err)
+		}
+
+		launchVersion, _ := launchLayer.Metadata[VersionKey].(string)
+		launchArch, _ := launchLayer.Metadata[ArchKey].(string)
+		launchUbuntuVersion, _ := launchLayer.Metadata[UbuntuVersionKey].(string)
+		launchLayoutVersion, _ := launchLayer.Metadata[LayoutVersionKey].(string)
+		launchIncludeApps, _ := launchLayer.Metadata[IncludeAppsKey].(string)
+		launchExcludeApps, _ := launchLayer.Metadata[ExcludeAppsKey].(string)
+		launchStripBeams, _ := launchLayer.Metadata[StripBeamsKey].(bool)
+		launchEpoch, _ := launchLayer.Metadata[SourceDateEpochKey].(string)
+
+		includeApps := strings.Join(apps.Include, ",")
+		excludeApps := strings.Join(apps.Exclude, ",")
+		stripBeams := os.Getenv("BP_ERLANG_STRIP_BEAMS") == "true"
+
+		if reused && launchVersion == version && launchArch == arch && launchUbuntuVersion == ubuntuVersion && launchLayoutVersion == LayoutVersion &&
+			launchIncludeApps == includeApps && launchExcludeApps == excludeApps && launchStripBeams == stripBeams && launchEpoch == epochKey {
+			logger.Process("Reusing launch layer %s", launchLayer.Path)
+			logger.Break()
+		} else {
+			logger.Process("Assembling launch layer")
+
+			launchLayer, err = launchLayer.Reset()
+			if err != nil {
+				return packit.BuildResult{}, fmt.Errorf("failed to reset %s layer: %w", LaunchLayerName, err)
+			}
+
+			stats, err := AssembleLaunchLayer(erlangLayer.Path, launchLayer.Path)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			logger.Subprocess("Copied %s, pruned %s of build-only files", formatBytes(uint64(stats.CopiedBytes)), formatBytes(uint64(stats.PrunedBytes)))
+
+			if !apps.IsEmpty() {
+				removed, err := PruneApplications(launchLayer.Path, apps)
+				if err != nil {
+					return packit.BuildResult{}, fmt.Errorf("failed to prune OTP applications: %w", err)
+				}
+
+				if len(removed) == 0 {
+					logger.Subprocess("No OTP applications pruned")
+				} else {
+					logger.Subprocess("Pruned OTP applications: %s", strings.Join(removed, ", "))
+				}
+			}
+
+			if stripBeams {
+				beamStats, err := StripBeams(launchLayer.Path)
+				if err != nil {
+					return packit.BuildResult{}, fmt.Errorf("failed to strip .beam files: %w", err)
+				}
+
+				logger.Subprocess("Stripped %d .beam files, saved %s", beamStats.Files, formatBytes(uint64(beamStats.SavedBytes)))
+			}
+			logger.Break()
+
+			err = NormalizeLayer(launchLayer.Path, epoch)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			launchLayer.LaunchEnv.Default("ERLANG_HOME", launchLayer.Path)
+			launchLayer.LaunchEnv.Prepend("PATH", filepath.Join(launchLayer.Path, "bin"), ":")
+
+			launchLayer.Metadata = map[string]any{
+				VersionKey:       version,
+				ArchKey:          arch,
+				UbuntuVersionKey: ubuntuVersion,
+				LayoutVersionKey: LayoutVersion,
+				IncludeAppsKey:   includeApps,
+				ExcludeAppsKey:   excludeApps,
+				StripBeamsKey:    stripBeams,
+
+				SourceDateEpochKey: epochKey,
+			}
+
+			logger.EnvironmentVariables(launchLayer)
+		}
+
+		launchLayer.Launch = true
+
+		launchLayer.SBOM, err = formatSBOM(launchLayer.Path, release, appsIn(otpApps, keptApps), context.BuildpackInfo.SBOMFormats)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		// read from the erlang layer, which is populated even when the launch
+		// layer is reused from the previous image
+		ertsVersion, err := ReadERTSVersion(erlangLayer.Path)
+		if err != nil {
+			return packit.BuildResult{}, fmt.Errorf("failed to read ERTS version: %w", err)
+		}
+
+		layers := []packit.Layer{erlangLayer, launchLayer}
+		var processes []packit.DirectProcess
+
+		// credentials for fetching dependencies are only handed to the rebar3
+		// and make processes below, through files in a temporary directory
+		// that is removed after the build, never to a layer
+		var fetchEnv []string
+		if planRequires(context.Plan, Rebar3) || planRequires(context.Plan, ErlangMk) {
+			hex, err := LoadHexConfig(bindingResolver, context.Platform.Path)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			gitAuth, err := LoadGitAuth(bindingResolver, context.Platform.Path)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			if !hex.IsEmpty() || !gitAuth.IsEmpty() {
+				credentialsDir, err := os.MkdirTemp("", "erlang-credentials")
+				if err != nil {
+					return packit.BuildResult{}, fmt.Errorf("failed to create credentials directory: %w", err)
+				}
+				defer os.RemoveAll(credentialsDir)
+
+				if !hex.IsEmpty() {
+					logger.Process("Configuring Hex from bindings: %s", strings.Join(hex.Bindings, ", "))
+					for _, line := range hex.Summary() {
+						logger.Subprocess(line)
+					}
+					logger.Break()
+
+					if len(hex.Repos) > 0 {
+						err = hex.WriteRebarConfig(credentialsDir)
+						if err != nil {
+							return packit.BuildResult{}, err
+						}
+					}
+
+					fetchEnv = append(fetchEnv, hex.Env(credentialsDir)...)
+				}
+
+				if !gitAuth.IsEmpty() {
+					logger.Process("Configuring git from bindings: %s", strings.Join(gitAuth.Bindings(), ", "))
+					for _, line := range gitAuth.Summary() {
+						logger.Subprocess(line)
+					}
+					logger.Break()
+
+					gitEnv, err := gitAuth.Write(credentialsDir)
+					if err != nil {
+						return packit.BuildResult{}, err
+					}
+
+					fetchEnv = append(fetchEnv, gitEnv...)
+				}
+			}
+		}
+
+		if rebar3Version, rebar3Source, ok := rebar3Request(context.Plan); ok {
+			rebar3Layer, err := buildRebar3Layer(context, rebar3Installer, rebar3Version, rebar3Source, version, epoch, logger)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+			packageCache, err := buildRebar3CacheLayer(context, logger)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			rebar3Layer.BuildEnv.Default("REBAR_CACHE_DIR", filepath.Join(packageCache.Path, "rebar3"))
+			rebar3Layer.BuildEnv.Default("HEX_HOME", filepath.Join(packageCache.Path, "hex"))
+
+			layers = append(layers, rebar3Layer, packageCache)
+
+			// erlang.mk builds its own release with relx
+			if !planRequires(context.Plan, ErlangMk) {
+				if os.Getenv("BP_ERLANG_RUN_DIALYZER") == "true" {
+					dialyzerLayer, err := runDialyzer(context, rebar3, rebar3Env(erlangLayer, rebar3Layer, packageCache, fetchEnv), version, logger, clock)
+					if err != nil {
+						return packit.BuildResult{}, err
+					}
+					layers = append(layers, dialyzerLayer)
+				}
+
+				releaseLayer, depsLayer, releaseProcesses, built, err := buildReleaseLayer(context, rebar3, erlangLayer, rebar3Layer, packageCache, version, fetchEnv, epoch, logger, clock)
+				if err != nil {
+					return packit.BuildResult{}, err
+				}
+				if built {
+					layers = append(layers, depsLayer, releaseLayer)
+					processes = append(processes, releaseProcesses...)
+				}
+
+				if value := os.Getenv("BP_ERLANG_RUN_TESTS"); value != "" {
+					tasks, err := TestTasks(value)
+					if err != nil {
+						return packit.BuildResult{}, err
+					}
+
+					reportsLayer, err := runTests(context, rebar3, tasks, rebar3Env(erlangLayer, rebar3Layer, packageCache, fetchEnv), logger, clock)
+					if err != nil {
+						return packit.BuildResult{}, err
+					}
+					layers = append(layers, reportsLayer)
+				}
+			}
+		}
+
+		if os.Getenv("BP_ERLANG_RUN_TESTS") != "" && (!planRequires(context.Plan, Rebar3) || planRequires(context.Plan, ErlangMk)) {
+			logger.Process("WARNING: BP_ERLANG_RUN_TESTS is only supported for rebar3 projects; no tests were run")
+			logger.Break()
+		}
+
+		if planRequires(context.Plan, ErlangMk) {
+			releaseLayer, cacheLayer, releaseProcesses, built, err := buildErlangMkRelease(context, gnuMake, erlangLayer, version, fetchEnv, epoch, logger, clock)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+			layers = append(layers, cacheLayer)
+			if built {
+				layers = append(layers, releaseLayer)
+				processes = append(processes, releaseProcesses...)
+			}
+		}
+
+		if !planRequires(context.Plan, Rebar3) && !planRequires(context.Plan, ErlangMk) {
+			project, plain, err := FindPlainProject(context.WorkingDir)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			if plain {
+				plainProcesses, err := buildPlainProject(context, project, erl, erlc, erlangLayer, launchLayer, logger, clock)
+				if err != nil {
+					return packit.BuildResult{}, err
+				}
+				processes = append(processes, plainProcesses...)
+			}
+		}
+
+		result := packit.BuildResult{
+			Layers: layers,
+			Launch: packit.LaunchMetadata{
+				Labels:          ImageLabels(version, ertsVersion, arch, downloadURL),
+				DirectProcesses: processes,
+			},
+		}
+
+		rebarLockPath := filepath.Join(context.WorkingDir, "rebar.lock")
+		deps, err := ParseRebarLock(rebarLockPath)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		if len(deps) > 0 {
+			logger.Process("Recording %d dependencies from rebar.lock in the SBOM", len(deps))
+			logger.Break()
+
+			result.Launch.SBOM, err = formatDependencySBOM(rebarLockPath, deps, context.BuildpackInfo.SBOMFormats)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+		}
+
+		mixLockPath := filepath.Join(context.WorkingDir, "mix.lock")
+		mixDeps, err := ParseMixLock(mixLockPath)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		if len(mixDeps) > 0 {
+			logger.Process("Recording %d dependencies from mix.lock in the build SBOM", len(mixDeps))
+			logger.Break()
+
+			result.Build.SBOM, err = formatDependencySBOM(mixLockPath, mixDeps, context.BuildpackInfo.SBOMFormats)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+		}
+
+		return result, nil
+	}
+}
+
+func formatSBOM(path string, release OTPRelease, apps []OTPApp, formats []string) (packit.SBOMFormatter, error) {
+	bom, err := GenerateSBOM(path, release, apps)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate SBOM for %s: %w", path, err)
+	}
+
+	formatter, err := bom.InFormats(formats...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format SBOM for %s: %w", path, err)
+	}
+
+	return formatter, nil
+}
+
+func formatDependencySBOM(path string, deps []LockedDependency, formats []string) (packit.SBOMFormatter, error) {
+	bom, err := GenerateDependencySBOM(path, deps)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate SBOM for %s: %w", path, err)
+	}
+
+	formatter, err := bom.InFormats(formats...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format SBOM for %s: %w", path, err)
+	}
+
+	return formatter, nil
+}
+
+// rebar3Request reports whether the plan asks for rebar3 and which version it
+// pins, if any. BP_REBAR3_VERSION takes precedence over the plan.
+func rebar3Request(plan packit.BuildpackPlan) (version, source string, ok bool) {
+	for _, entry := range plan.Entries {
+		if entry.Name != Rebar3 {
+			continue
+		}
+
+		if version := os.Getenv("BP_REBAR3_VERSION"); version != "" {
+			return version, "BP_REBAR3_VERSION", true
+		}
+
+		version, _ := entry.Metadata["version"].(string)
+		source, _ := entry.Metadata["version-source"].(string)
+		return version, source, true
+	}
+
+	return "", "", false
+}
+
+// toolVersionsPin returns the erlang version the plan carries from
+// .tool-versions, if any.
+func toolVersionsPin(plan packit.BuildpackPlan) string {
+	for _, entry := range plan.Entries {
+		if entry.Name != Erlang {
+			continue
+		}
+
+		version, _ := entry.Metadata["version"].(string)
+		source, _ := entry.Metadata["version-source"].(string)
+		if source == ".tool-versions" {
+			return version
+		}
+	}
+
+	return ""
+}
+
+func planRequires(plan packit.BuildpackPlan, name string) bool {
+	for _, entry := range plan.Entries {
+		if entry.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func buildRebar3Layer(context packit.BuildContext, rebar3Installer Rebar3Installer, version, versionSource, otpVersion string, epoch time.Time, logger scribe.Emitter) (packit.Layer, error) {
+	logger.Process("Resolving rebar3 version")
+
+	layer, err := context.Layers.Get(Rebar3LayerName)
+	if err != nil {
+		return packit.Layer{}, fmt.Errorf("failed to get %s layer: %w", Rebar3LayerName, err)
+	}
+
+	cachedVersion, _ := layer.Metadata[VersionKey].(string)
+	cachedSHA256, _ := layer.Metadata[SHA256Key].(string)
+	cachedEpoch, _ := layer.Metadata[SourceDateEpochKey].(string)
+	epochKey := strconv.FormatInt(epoch.Unix(), 10)
+
+	cacheValid := func(release Rebar3Release) bool {
+		return release.Version == cachedVersion && release.SHA256 == cachedSHA256 && cachedEpoch == epochKey &&
+			verifyFileSHA256(filepath.Join(layer.Path, "bin", "rebar3"), cachedSHA256) == nil
+	}
+
+	// a pinned version that is already cached needs no network access
+	release := Rebar3Release{Version: version, SHA256: cachedSHA256}
+	if version == "" || !cacheValid(release) {
+		release, err = rebar3Installer.ResolveRelease(version)
+		if err != nil {
+			return packit.Layer{}, fmt.Errorf("failed to resolve rebar3 version: %w", err)
+		}
+	}
+
+	if versionSource != "" {
+		logger.Action("Using rebar3 version: %s (from %s)", release.Version, versionSource)
+	} else {
+		logger.Action("Using rebar3 version: %s", release.Version)
+	}
+	logger.Break()
+
+	compat, known, err := LookupRebar3Compatibility(release.Version)
+	if err != nil {
+		return packit.Layer{}, err
+	}
+
+	if known {
+		err = compat.CheckOTP(otpVersion)
+		if err != nil {
+			return packit.Layer{}, err
+		}
+
+		if !compat.IsTestedWith(otpVersion) {
+			logger.Process("WARNING: rebar3 %s has not been tested with OTP %s", release.Version, otpVersion)
+			logger.Subprocess("rebar3 %s supports OTP %d to %d", compat.Rebar3, compat.MinOTP, compat.MaxOTP)
+			logger.Break()
+		}
+	} else {
+		logger.Subprocess("No OTP compatibility data for rebar3 %s", release.Version)
+		logger.Break()
+	}
+
+	if cacheValid(release) {
+		logger.Process("Reusing cached layer %s", layer.Path)
+		logger.Break()
+	} else {
+		logger.Process("Installing rebar3 %s", release.Version)
+		logger.Action("Source: %s", release.URL)
+
+		layer, err = layer.Reset()
+		if err != nil {
+			return packit.Layer{}, fmt.Errorf("failed to reset %s layer: %w", Rebar3LayerName, err)
+		}
+
+		err = rebar3Installer.Install(release, layer.Path)
+		if err != nil {
+			return packit.Layer{}, fmt.Errorf("failed to install rebar3 %s: %w", release.Version, err)
+		}
+		logger.Break()
+
+		err = NormalizeLayer(layer.Path, epoch)
+		if err != nil {
+			return packit.Layer{}, err
+		}
+
+		layer.BuildEnv.Prepend("PATH", filepath.Join(layer.Path, "bin"), ":")
+
+		layer.Metadata = map[string]any{
+			VersionKey:         release.Version,
+			SHA256Key:          release.SHA256,
+			SourceDateEpochKey: epochKey,
+		}
+
+		logger.EnvironmentVariables(layer)
+	}
+
+	layer.Build = true
+	layer.Cache = true
+
+	return layer, nil
+}
+
+// buildRebar3CacheLayer keeps the rebar3 package cache and the Hex home
+// between builds, in the rebar3 and hex directories of a cache-only layer. It
+// starts afresh whenever rebar.lock changes.
+func buildRebar3CacheLayer(context packit.BuildContext, logger scribe.Emitter) (packit.Layer, error) {
+	layer, err := context.Layers.Get(Rebar3CacheLayerName)
+	if err != nil {
+		return packit.Layer{}, fmt.Errorf("failed to get %s layer: %w", Rebar3CacheLayerName, err)
+	}
+
+	digest, err := RebarLockDigest(filepath.Join(context.WorkingDir, "rebar.lock"))
+	if err != nil {
+		return packit.Layer{}, err
+	}
+
+	cachedDigest, cached := layer.Metadata[RebarLockSHA256Key].(string)
+	if cached && cachedDigest == digest {
+		logger.Process("Reusing cached layer %s", layer.Path)
+		logger.Break()
+	} else {
+		if cached {
+			logger.Process("Discarding package cache: rebar.lock has changed")
+			logger.Break()
+		}
+
+		layer, err = layer.Reset()
+		if err != nil {
+			return packit.Layer{}, fmt.Errorf("failed to reset %s layer: %w", Rebar3CacheLayerName, err)
+		}
+	}
+
+	for _, dir := range []string{"rebar3", "hex"} {
+		err = os.MkdirAll(filepath.Join(layer.Path, dir), os.ModePerm)
 		if err != nil {
-			return packit.BuildResult{}, fmt.Errorf("failed to install Erlang %s: %w", version, err)
+			return packit.Layer{}, fmt.Errorf("failed to create %s directory in %s layer: %w", dir, Rebar3CacheLayerName, err)
+		}
+	}
+
+	layer.Cache = true
+	layer.Metadata = map[string]any{
+		RebarLockSHA256Key: digest,
+	}
+
+	return layer, nil
+}
+
+// rebar3Env returns the environment rebar3 runs in: erlang and rebar3 on the
+// PATH, the package caches and any credentials for fetching dependencies.
+func rebar3Env(erlangLayer, rebar3Layer, packageCache packit.Layer, fetchEnv []string) []string {
+	path := strings.Join([]string{filepath.Join(rebar3Layer.Path, "bin"), filepath.Join(erlangLayer.Path, "bin"), os.Getenv("PATH")}, string(os.PathListSeparator))
+
+	env := append(os.Environ(), "PATH="+path)
+	if os.Getenv("REBAR_CACHE_DIR") == "" {
+		env = append(env, "REBAR_CACHE_DIR="+filepath.Join(packageCache.Path, "rebar3"))
+	}
+	if os.Getenv("HEX_HOME") == "" {
+		env = append(env, "HEX_HOME="+filepath.Join(packageCache.Path, "hex"))
+	}
+	return append(env, fetchEnv...)
+}
+
+// runDialyzer runs "rebar3 dialyzer" with its PLTs in a cache layer, which is
+// only reused for the same OTP version and PLT applications. Warnings fail
+// the build only when BP_ERLANG_DIALYZER_STRICT is true.
+func runDialyzer(context packit.BuildContext, rebar3 Executable, env []string, otpVersion string, logger scribe.Emitter, clock chronos.Clock) (packit.Layer, error) {
+	apps, err := DialyzerPLTApps(filepath.Join(context.WorkingDir, "rebar.config"))
+	if err != nil {
+		return packit.Layer{}, err
+	}
+	appsKey := strings.Join(apps, ",")
+
+	layer, err := context.Layers.Get(DialyzerLayerName)
+	if err != nil {
+		return packit.Layer{}, fmt.Errorf("failed to get %s layer: %w", DialyzerLayerName, err)
+	}
+
+	cachedVersion, cached := layer.Metadata[VersionKey].(string)
+	cachedApps, _ := layer.Metadata[PLTAppsKey].(string)
+
+	if cached && cachedVersion == otpVersion && cachedApps == appsKey {
+		logger.Process("Reusing cached layer %s", layer.Path)
+	} else {
+		if cached {
+			logger.Process("Discarding PLTs built for OTP %s with %s", cachedVersion, cachedApps)
+		}
+
+		layer, err = layer.Reset()
+		if err != nil {
+			return packit.Layer{}, fmt.Errorf("failed to reset %s layer: %w", DialyzerLayerName, err)
+		}
+	}
+
+	logger.Process("Running rebar3 dialyzer")
+	logger.Subprocess("PLT applications: %s", strings.Join(apps, ", "))
+
+	var output bytes.Buffer
+	writer := io.MultiWriter(logger.ActionWriter, &output)
+
+	duration, err := clock.Measure(func() error {
+		return rebar3.Execute(pexec.Execution{
+			Args:   []string{"dialyzer", "--base-plt-location", layer.Path, "--plt-location", layer.Path},
+			Dir:    context.WorkingDir,
+			Env:    env,
+			Stdout: writer,
+			Stderr: writer,
+		})
+	})
+	if err != nil {
+		if !dialyzerReportedWarnings(output.String()) {
+			return packit.Layer{}, fmt.Errorf("failed to run dialyzer: %w", err)
+		}
+
+		if os.Getenv("BP_ERLANG_DIALYZER_STRICT") == "true" {
+			return packit.Layer{}, fmt.Errorf("dialyzer reported warnings and BP_ERLANG_DIALYZER_STRICT is set")
+		}
+
+		logger.Process("WARNING: dialyzer reported warnings")
+		logger.Subprocess("Set BP_ERLANG_DIALYZER_STRICT=true to fail the build on them")
+	}
+
+	logger.Action("Completed in %s", duration.Round(time.Millisecond).String())
+	logger.Break()
+
+	layer.Cache = true
+	layer.Metadata = map[string]any{
+		VersionKey: otpVersion,
+		PLTAppsKey: appsKey,
+	}
+
+	return layer, nil
+}
+
+// runTests runs the rebar3 test tasks one after the other and collects their
+// XML reports into a build-only layer. Every task runs even when an earlier
+// one fails, so that the error names all of the failed cases.
+func runTests(context packit.BuildContext, rebar3 Executable, tasks []string, env []string, logger scribe.Emitter, clock chronos.Clock) (packit.Layer, error) {
+	layer, err := context.Layers.Get(TestReportsLayerName)
+	if err != nil {
+		return packit.Layer{}, fmt.Errorf("failed to get %s layer: %w", TestReportsLayerName, err)
+	}
+
+	layer, err = layer.Reset()
+	if err != nil {
+		return packit.Layer{}, fmt.Errorf("failed to reset %s layer: %w", TestReportsLayerName, err)
+	}
+
+	var failedTasks, failed []string
+	for _, task := range tasks {
+		logger.Process("Running rebar3 %s", task)
+
+		var output bytes.Buffer
+		writer := io.MultiWriter(logger.ActionWriter, &output)
+
+		duration, runErr := clock.Measure(func() error {
+			return rebar3.Execute(pexec.Execution{
+				Args:   TestTaskArgs(task, layer.Path),
+				Dir:    context.WorkingDir,
+				Env:    env,
+				Stdout: writer,
+				Stderr: writer,
+			})
+		})
+
+		// Common Test already wrote its logs into the layer
+		if task == EUnit {
+			_, err := CollectSurefireReports(context.WorkingDir, layer.Path, task)
+			if err != nil {
+				return packit.Layer{}, err
+			}
 		}
 
 		logger.Action("Completed in %s", duration.Round(time.Millisecond).String())
 		logger.Break()
 
-		// setup env variables
-		erlangLayer.SharedEnv.Default("ERLANG_HOME", erlangLayer.Path)
-		erlangLayer.SharedEnv.Prepend("PATH", filepath.Join(erlangLayer.Path, "bin"), ":")
+		if runErr == nil {
+			continue
+		}
+
+		failedTasks = append(failedTasks, task)
 
-		// store metadata for caching
-		erlangLayer.Metadata = map[string]any{
-			VersionKey:       version,
-			ArchKey:          arch,
-			UbuntuVersionKey: ubuntuVersion,
+		cases, err := FailedTestCases(filepath.Join(layer.Path, task))
+		if err != nil {
+			return packit.Layer{}, err
+		}
+		if len(cases) == 0 {
+			cases = FailedOutputCases(output.String())
 		}
+		failed = append(failed, cases...)
+	}
 
-		erlangLayer.Launch = true
-		erlangLayer.Cache = true
-		erlangLayer.Build = true
+	logger.Process("Test reports in %s", layer.Path)
+	logger.Break()
+
+	if len(failedTasks) > 0 {
+		return packit.Layer{}, fmt.Errorf("rebar3 %s failed: %s", strings.Join(failedTasks, " and "), TestFailureSummary(failed))
+	}
+
+	layer.Build = true
+
+	return layer, nil
+}
+
+// buildReleaseLayer runs "rebar3 as <profile> release" when rebar.config has a
+// relx section and copies the release into a launch layer. Compiled
+// dependencies are restored from, and saved to, a cache layer that is only
+// valid for the same rebar.lock, OTP version and profile. It reports false
+// when the app declares no release.
+func buildReleaseLayer(context packit.BuildContext, rebar3 Executable, erlangLayer, rebar3Layer, packageCache packit.Layer, otpVersion string, fetchEnv []string, epoch time.Time, logger scribe.Emitter, clock chronos.Clock) (packit.Layer, packit.Layer, []packit.DirectProcess, bool, error) {
+	profile := os.Getenv("BP_REBAR3_PROFILE")
+	if profile == "" {
+		profile = DefaultRebar3Profile
+	}
+
+	relx, ok, err := ParseRelxConfig(filepath.Join(context.WorkingDir, "rebar.config"), profile)
+	if err != nil {
+		return packit.Layer{}, packit.Layer{}, nil, false, err
+	}
+	if !ok {
+		return packit.Layer{}, packit.Layer{}, nil, false, nil
+	}
+
+	release, err := relx.Select(os.Getenv("BP_ERLANG_RELEASE_NAME"))
+	if err != nil {
+		return packit.Layer{}, packit.Layer{}, nil, false, err
+	}
+
+	lockPath := filepath.Join(context.WorkingDir, "rebar.lock")
+	lockDigest, err := RebarLockDigest(lockPath)
+	if err != nil {
+		return packit.Layer{}, packit.Layer{}, nil, false, err
+	}
+
+	lockedDeps, err := ParseRebarLock(lockPath)
+	if err != nil {
+		return packit.Layer{}, packit.Layer{}, nil, false, fmt.Errorf("failed to parse rebar.lock: %w", err)
+	}
+
+	depsLayer, err := context.Layers.Get(Rebar3DepsLayerName)
+	if err != nil {
+		return packit.Layer{}, packit.Layer{}, nil, false, fmt.Errorf("failed to get %s layer: %w", Rebar3DepsLayerName, err)
+	}
+
+	libDir := filepath.Join(context.WorkingDir, "_build", profile, "lib")
+
+	cachedDigest, cached := depsLayer.Metadata[RebarLockSHA256Key].(string)
+	cachedVersion, _ := depsLayer.Metadata[VersionKey].(string)
+	cachedProfile, _ := depsLayer.Metadata[ProfileKey].(string)
+
+	switch {
+	case !cached:
+	case cachedVersion != otpVersion:
+		logger.Process("Discarding dependency cache: built with OTP %s", cachedVersion)
+	case cachedProfile != profile:
+		logger.Process("Discarding dependency cache: built as %s", cachedProfile)
+	case cachedDigest != lockDigest:
+		logger.Process("Discarding dependency cache: rebar.lock has changed")
+	default:
+		restored, err := RestoreDependencies(filepath.Join(depsLayer.Path, "lib"), libDir)
+		if err != nil {
+			return packit.Layer{}, packit.Layer{}, nil, false, err
+		}
+		logger.Process("Restored %d compiled dependencies from %s", len(restored), depsLayer.Path)
+	}
+
+	logger.Process("Building release %s with rebar3 as %s", release.Name, profile)
+
+	duration, err := clock.Measure(func() error {
+		return rebar3.Execute(pexec.Execution{
+			Args:   []string{"as", profile, "release", "-n", release.Name},
+			Dir:    context.WorkingDir,
+			Env:    rebar3Env(erlangLayer, rebar3Layer, packageCache, fetchEnv),
+			Stdout: logger.ActionWriter,
+			Stderr: logger.ActionWriter,
+		})
+	})
+	if err != nil {
+		return packit.Layer{}, packit.Layer{}, nil, false, fmt.Errorf("failed to build release %s: %w", release.Name, err)
+	}
+
+	logger.Action("Completed in %s", duration.Round(time.Millisecond).String())
+	logger.Break()
+
+	depsLayer, err = depsLayer.Reset()
+	if err != nil {
+		return packit.Layer{}, packit.Layer{}, nil, false, fmt.Errorf("failed to reset %s layer: %w", Rebar3DepsLayerName, err)
+	}
+
+	saved, err := SaveDependencies(libDir, filepath.Join(depsLayer.Path, "lib"), lockedNames(lockedDeps))
+	if err != nil {
+		return packit.Layer{}, packit.Layer{}, nil, false, err
+	}
+	logger.Subprocess("Cached %d compiled dependencies", len(saved))
+	logger.Break()
+
+	depsLayer.Cache = true
+	depsLayer.Metadata = map[string]any{
+		RebarLockSHA256Key: lockDigest,
+		VersionKey:         otpVersion,
+		ProfileKey:         profile,
+	}
+
+	releaseDir := filepath.Join(context.WorkingDir, "_build", profile, "rel", release.Name)
+	layer, processes, err := installRelease(context, releaseDir, release, map[string]any{
+		"release":  release.Name,
+		ProfileKey: profile,
+		VersionKey: release.Version,
+	}, epoch, logger)
+	if err != nil {
+		return packit.Layer{}, packit.Layer{}, nil, false, err
+	}
+
+	return layer, depsLayer, processes, true, nil
+}
 
-		logger.EnvironmentVariables(erlangLayer)
+// buildErlangMkRelease runs make with the BP_ERLANG_MK_TARGETS targets. The
+// erlang.mk build directory and the fetched and compiled dependencies are
+// kept in a cache layer, discarded when the OTP version or the dependency
+// declarations in the Makefile change. When make produced a release under
+// _rel, it is copied into a launch layer; the cache layer is returned either
+// way.
+func buildErlangMkRelease(context packit.BuildContext, gnuMake Executable, erlangLayer packit.Layer, otpVersion string, fetchEnv []string, epoch time.Time, logger scribe.Emitter, clock chronos.Clock) (packit.Layer, packit.Layer, []packit.DirectProcess, bool, error) {
+	cacheLayer, err := context.Layers.Get(ErlangMkCacheLayerName)
+	if err != nil {
+		return packit.Layer{}, packit.Layer{}, nil, false, fmt.Errorf("failed to get %s layer: %w", ErlangMkCacheLayerName, err)
+	}
+
+	depsDigest, err := ErlangMkDepsDigest(context.WorkingDir)
+	if err != nil {
+		return packit.Layer{}, packit.Layer{}, nil, false, err
+	}
+
+	cachedVersion, cached := cacheLayer.Metadata[VersionKey].(string)
+	cachedDigest, _ := cacheLayer.Metadata[DepsSpecSHA256Key].(string)
+
+	tmpDir := filepath.Join(cacheLayer.Path, "tmp")
+	depsDir := filepath.Join(cacheLayer.Path, "deps")
+
+	reused := false
+	switch {
+	case !cached:
+	case cachedVersion != otpVersion:
+		logger.Process("Discarding erlang.mk cache: built with OTP %s", cachedVersion)
+	case cachedDigest != depsDigest:
+		logger.Process("Discarding erlang.mk cache: the Makefile declares different dependencies")
+	default:
+		logger.Process("Reusing cached layer %s", cacheLayer.Path)
+
+		// erlang.mk records the dependencies it went through in these logs;
+		// the cached ones are skipped anyway because they are already built
+		for _, log := range []string{"deps.log", "apps.log"} {
+			err = os.RemoveAll(filepath.Join(tmpDir, log))
+			if err != nil {
+				return packit.Layer{}, packit.Layer{}, nil, false, fmt.Errorf("failed to remove %s from %s layer: %w", log, ErlangMkCacheLayerName, err)
+			}
+		}
+		reused = true
+	}
+
+	if !reused {
+		cacheLayer, err = cacheLayer.Reset()
+		if err != nil {
+			return packit.Layer{}, packit.Layer{}, nil, false, fmt.Errorf("failed to reset %s layer: %w", ErlangMkCacheLayerName, err)
+		}
+	}
+
+	targets := ErlangMkTargets()
+	logger.Process("Running make %s", strings.Join(targets, " "))
+
+	path := strings.Join([]string{filepath.Join(erlangLayer.Path, "bin"), os.Getenv("PATH")}, string(os.PathListSeparator))
+
+	duration, err := clock.Measure(func() error {
+		return gnuMake.Execute(pexec.Execution{
+			Args:   targets,
+			Dir:    context.WorkingDir,
+			Env:    append(append(os.Environ(), "PATH="+path, "ERLANG_MK_TMP="+tmpDir, "DEPS_DIR="+depsDir), fetchEnv...),
+			Stdout: logger.ActionWriter,
+			Stderr: logger.ActionWriter,
+		})
+	})
+	if err != nil {
+		return packit.Layer{}, packit.Layer{}, nil, false, fmt.Errorf("failed to run make %s: %w", strings.Join(targets, " "), err)
+	}
+
+	logger.Action("Completed in %s", duration.Round(time.Millisecond).String())
+	logger.Break()
+
+	cacheLayer.Cache = true
+	cacheLayer.Metadata = map[string]any{
+		VersionKey:        otpVersion,
+		DepsSpecSHA256Key: depsDigest,
+	}
 
-		return packit.BuildResult{
-			Layers: []packit.Layer{erlangLayer},
-		}, nil
+	relx, ok, err := ErlangMkReleases(context.WorkingDir)
+	if err != nil {
+		return packit.Layer{}, packit.Layer{}, nil, false, err
 	}
+	if !ok {
+		return packit.Layer{}, cacheLayer, nil, false, nil
+	}
+
+	release, err := relx.Select(os.Getenv("BP_ERLANG_RELEASE_NAME"))
+	if err != nil {
+		return packit.Layer{}, packit.Layer{}, nil, false, err
+	}
+
+	releaseDir := filepath.Join(context.WorkingDir, ErlangMkReleaseDir, release.Name)
+	layer, processes, err := installRelease(context, releaseDir, release, map[string]any{
+		"release":  release.Name,
+		VersionKey: release.Version,
+	}, epoch, logger)
+	if err != nil {
+		return packit.Layer{}, packit.Layer{}, nil, false, err
+	}
+
+	return layer, cacheLayer, processes, true, nil
+}
+
+// buildPlainProject compiles a project that uses neither rebar3 nor erlang.mk
+// in place, with "erl -make" or erlc. When BP_ERLANG_START_MODULE is set, it
+// returns a process that starts the compiled code with the launch layer's erl.
+func buildPlainProject(context packit.BuildContext, project PlainProject, erl, erlc Executable, erlangLayer, launchLayer packit.Layer, logger scribe.Emitter, clock chronos.Clock) ([]packit.DirectProcess, error) {
+	compiler, name := erlc, "erlc"
+	if project.Emakefile != "" {
+		compiler, name = erl, "erl"
+		logger.Process("Compiling with erl -make")
+	} else {
+		logger.Process("Compiling %d modules with erlc", len(project.Sources))
+
+		err := os.MkdirAll(filepath.Join(context.WorkingDir, "ebin"), os.ModePerm)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create ebin directory: %w", err)
+		}
+	}
+
+	path := strings.Join([]string{filepath.Join(erlangLayer.Path, "bin"), os.Getenv("PATH")}, string(os.PathListSeparator))
+
+	duration, err := clock.Measure(func() error {
+		return compiler.Execute(pexec.Execution{
+			Args:   project.CompileArgs(),
+			Dir:    context.WorkingDir,
+			Env:    append(os.Environ(), "PATH="+path),
+			Stdout: logger.ActionWriter,
+			Stderr: logger.ActionWriter,
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile with %s: %w", name, err)
+	}
+
+	logger.Action("Completed in %s", duration.Round(time.Millisecond).String())
+	logger.Break()
+
+	module := os.Getenv("BP_ERLANG_START_MODULE")
+	if module == "" {
+		logger.Process("No launch process: set BP_ERLANG_START_MODULE to start the compiled code")
+		logger.Break()
+		return nil, nil
+	}
+
+	codePaths, err := project.CodePaths()
+	if err != nil {
+		return nil, err
+	}
+
+	processes := []packit.DirectProcess{
+		{
+			Type:             "web",
+			Command:          StartCommand(filepath.Join(launchLayer.Path, "bin", "erl"), codePaths, module, os.Getenv("BP_ERLANG_START_FUNCTION")),
+			Default:          true,
+			WorkingDirectory: context.WorkingDir,
+		},
+	}
+
+	logger.LaunchDirectProcesses(processes)
+
+	return processes, nil
+}
+
+// installRelease copies the release built in releaseDir into the release
+// launch layer and returns the processes that start it.
+func installRelease(context packit.BuildContext, releaseDir string, release RelxRelease, metadata map[string]any, epoch time.Time, logger scribe.Emitter) (packit.Layer, []packit.DirectProcess, error) {
+	layer, err := context.Layers.Get(ReleaseLayerName)
+	if err != nil {
+		return packit.Layer{}, nil, fmt.Errorf("failed to get %s layer: %w", ReleaseLayerName, err)
+	}
+
+	layer, err = layer.Reset()
+	if err != nil {
+		return packit.Layer{}, nil, fmt.Errorf("failed to reset %s layer: %w", ReleaseLayerName, err)
+	}
+
+	err = fs.Copy(releaseDir, layer.Path)
+	if err != nil {
+		return packit.Layer{}, nil, fmt.Errorf("failed to copy release %s into %s layer: %w", release.Name, ReleaseLayerName, err)
+	}
+
+	err = NormalizeLayer(layer.Path, epoch)
+	if err != nil {
+		return packit.Layer{}, nil, err
+	}
+
+	layer.Launch = true
+	layer.Metadata = metadata
+
+	command := []string{filepath.Join(layer.Path, "bin", release.Name), "foreground"}
+	processes := []packit.DirectProcess{
+		{Type: "web", Command: command, Default: true},
+		{Type: "release", Command: command},
+	}
+
+	logger.LaunchDirectProcesses(processes)
+
+	return layer, processes, nil
+}
+
+// verifyCachedLayer checks that a restored layer was assembled by the current
+// layout and matches the manifest stored in its metadata, so that a stale or
+// incomplete cache is never shipped.
+func verifyCachedLayer(layer packit.Layer, layoutVersion string) error {
+	if layoutVersion != LayoutVersion {
+		return fmt.Errorf("layer layout version %q does not match buildpack layout version %q", layoutVersion, LayoutVersion)
+	}
+
+	manifest, ok := ManifestFromMetadata(layer.Metadata)
+	if !ok {
+		return fmt.Errorf("no manifest recorded in layer metadata")
+	}
+
+	return manifest.Verify(layer.Path)
 }
 
 func detectUbuntuVersion(stackID string) (string, error) {
@@ -2,8 +2,15 @@ package erlang_test
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -11,7 +18,10 @@ import (
 	"github.com/SnakeDoc/erlang-cnb/fakes"
 	"github.com/paketo-buildpacks/packit/v2"
 	"github.com/paketo-buildpacks/packit/v2/chronos"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+	"github.com/paketo-buildpacks/packit/v2/sbom"
 	"github.com/paketo-buildpacks/packit/v2/scribe"
+	"github.com/paketo-buildpacks/packit/v2/servicebindings"
 	"github.com/sclevine/spec"
 
 	. "github.com/onsi/gomega"
@@ -21,12 +31,19 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 	var (
 		Expect = NewWithT(t).Expect
 
-		layersDir  string
-		workingDir string
-		cnbDir     string
-		buffer     *bytes.Buffer
-		timeStamp  time.Time
-		installer  *fakes.Installer
+		layersDir       string
+		workingDir      string
+		cnbDir          string
+		buffer          *bytes.Buffer
+		timeStamp       time.Time
+		installer       *fakes.Installer
+		rebar3Installer *fakes.Rebar3Installer
+		rebar3          *fakes.Executable
+		gnuMake         *fakes.Executable
+		erl             *fakes.Executable
+		erlc            *fakes.Executable
+		versionLister   *fakes.VersionLister
+		bindingResolver *fakes.BindingResolver
 
 		build        packit.BuildFunc
 		buildContext packit.BuildContext
@@ -48,9 +65,23 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		buffer = bytes.NewBuffer(nil)
 		timeStamp = time.Now()
 		installer = &fakes.Installer{}
+		rebar3Installer = &fakes.Rebar3Installer{}
+		rebar3 = &fakes.Executable{}
+		gnuMake = &fakes.Executable{}
+		erl = &fakes.Executable{}
+		erlc = &fakes.Executable{}
+		versionLister = &fakes.VersionLister{}
+		bindingResolver = &fakes.BindingResolver{}
 
 		build = erlang.Build(
 			installer,
+			rebar3Installer,
+			rebar3,
+			gnuMake,
+			erl,
+			erlc,
+			versionLister,
+			bindingResolver,
 			scribe.NewEmitter(buffer),
 			chronos.NewClock(func() time.Time { return timeStamp }),
 		)
@@ -59,8 +90,9 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 			CNBPath: cnbDir,
 			Stack:   "io.buildpacks.stacks.jammy",
 			BuildpackInfo: packit.BuildpackInfo{
-				Name:    "Some Erlang Buildpack",
-				Version: "0.0.1",
+				Name:        "Some Erlang Buildpack",
+				Version:     "0.0.1",
+				SBOMFormats: []string{sbom.CycloneDXFormat, sbom.SPDXFormat, sbom.SyftFormat},
 			},
 			Layers:     packit.Layers{Path: layersDir},
 			WorkingDir: workingDir,
@@ -78,23 +110,41 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		result, err := build(buildContext)
 		Expect(err).NotTo(HaveOccurred())
 
-		Expect(result.Layers).To(HaveLen(1))
+		Expect(result.Layers).To(HaveLen(2))
 		layer := result.Layers[0]
 
 		Expect(layer.Name).To(Equal("erlang"))
 		Expect(layer.Path).To(Equal(filepath.Join(layersDir, "erlang")))
 
-		Expect(layer.SharedEnv).To(HaveKeyWithValue("ERLANG_HOME.default", filepath.Join(layersDir, "erlang")))
-		Expect(layer.SharedEnv).To(HaveKeyWithValue("PATH.prepend", filepath.Join(layersDir, "erlang", "bin")))
-		Expect(layer.SharedEnv).To(HaveKeyWithValue("PATH.delim", ":"))
+		Expect(layer.BuildEnv).To(HaveKeyWithValue("ERLANG_HOME.default", filepath.Join(layersDir, "erlang")))
+		Expect(layer.BuildEnv).To(HaveKeyWithValue("PATH.prepend", filepath.Join(layersDir, "erlang", "bin")))
+		Expect(layer.BuildEnv).To(HaveKeyWithValue("PATH.delim", ":"))
 
 		Expect(layer.Build).To(BeTrue())
-		Expect(layer.Launch).To(BeTrue())
+		Expect(layer.Launch).To(BeFalse())
 		Expect(layer.Cache).To(BeTrue())
 
 		Expect(layer.Metadata).To(HaveKeyWithValue("version", "28.1.1"))
 		Expect(layer.Metadata).To(HaveKeyWithValue("arch", "amd64"))
 		Expect(layer.Metadata).To(HaveKeyWithValue("ubuntu-version", "ubuntu-22.04"))
+		Expect(layer.Metadata).To(HaveKeyWithValue("layout-version", erlang.LayoutVersion))
+		Expect(layer.Metadata).To(HaveKeyWithValue("file-count", 1))
+		Expect(layer.Metadata).To(HaveKey("checksum"))
+
+		launchLayer := result.Layers[1]
+
+		Expect(launchLayer.Name).To(Equal("erlang-launch"))
+		Expect(launchLayer.Path).To(Equal(filepath.Join(layersDir, "erlang-launch")))
+
+		Expect(launchLayer.LaunchEnv).To(HaveKeyWithValue("ERLANG_HOME.default", filepath.Join(layersDir, "erlang-launch")))
+		Expect(launchLayer.LaunchEnv).To(HaveKeyWithValue("PATH.prepend", filepath.Join(layersDir, "erlang-launch", "bin")))
+
+		Expect(launchLayer.Build).To(BeFalse())
+		Expect(launchLayer.Launch).To(BeTrue())
+		Expect(launchLayer.Cache).To(BeFalse())
+
+		Expect(launchLayer.Metadata).To(HaveKeyWithValue("version", "28.1.1"))
+		Expect(launchLayer.Metadata).To(HaveKeyWithValue("layout-version", erlang.LayoutVersion))
 
 		Expect(buffer.String()).To(ContainSubstring("Some Erlang Buildpack 0.0.1"))
 		Expect(buffer.String()).To(ContainSubstring("Resolving Erlang version"))
@@ -103,19 +153,1654 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		Expect(buffer.String()).To(ContainSubstring("Using Erlang version: 28.1.1"))
 		Expect(buffer.String()).To(ContainSubstring("Executing build process"))
 		Expect(buffer.String()).To(ContainSubstring("Downloading Erlang 28.1.1"))
+		Expect(buffer.String()).To(ContainSubstring("Assembling launch layer"))
+	})
+
+	it("prunes build-only files from the launch layer", func() {
+		installer.InstallCall.Stub = func(_, layerPath string) (string, error) {
+			for _, dir := range []string{"bin", "include", "lib/stdlib-7.1/ebin", "lib/stdlib-7.1/src", "usr/lib"} {
+				Expect(os.MkdirAll(filepath.Join(layerPath, dir), os.ModePerm)).To(Succeed())
+			}
+			Expect(os.WriteFile(filepath.Join(layerPath, "bin", "erl"), []byte("ROOTDIR="+layerPath), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(layerPath, "include", "erl_nif.h"), []byte("header"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(layerPath, "lib/stdlib-7.1/ebin", "lists.beam"), []byte("beam"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(layerPath, "lib/stdlib-7.1/src", "lists.erl"), []byte("source"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(layerPath, "usr/lib", "liberts.a"), []byte("archive"), 0644)).To(Succeed())
+			return "some-sha256", nil
+		}
+
+		_, err := build(buildContext)
+		Expect(err).NotTo(HaveOccurred())
+
+		launchPath := filepath.Join(layersDir, "erlang-launch")
+		Expect(filepath.Join(launchPath, "lib/stdlib-7.1/ebin/lists.beam")).To(BeARegularFile())
+		Expect(filepath.Join(launchPath, "lib/stdlib-7.1/src")).NotTo(BeAnExistingFile())
+		Expect(filepath.Join(launchPath, "include")).NotTo(BeAnExistingFile())
+		Expect(filepath.Join(launchPath, "usr/lib/liberts.a")).NotTo(BeAnExistingFile())
+
+		content, err := os.ReadFile(filepath.Join(launchPath, "bin", "erl"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(content)).To(Equal("ROOTDIR=" + launchPath))
+
+		Expect(filepath.Join(layersDir, "erlang", "include", "erl_nif.h")).To(BeARegularFile())
+	})
+
+	it("attaches SBOMs describing OTP and its applications", func() {
+		installer.InstallCall.Stub = func(_, layerPath string) (string, error) {
+			for name, version := range map[string]string{"kernel": "10.4", "stdlib": "7.1", "wx": "2.5"} {
+				ebin := filepath.Join(layerPath, "lib", name+"-"+version, "ebin")
+				Expect(os.MkdirAll(ebin, os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(ebin, name+".app"), []byte(fmt.Sprintf("{application, %s, []}.", name)), 0644)).To(Succeed())
+			}
+			return "some-sha256", nil
+		}
+		installer.BuildDownloadURLCall.Returns.String = "https://example.com/OTP-28.1.1.tar.gz"
+		Expect(os.Setenv("BP_ERLANG_EXCLUDE_APPS", "wx")).To(Succeed())
+		defer os.Unsetenv("BP_ERLANG_EXCLUDE_APPS")
+
+		result, err := build(buildContext)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(result.Layers[0].Metadata).To(HaveKeyWithValue("sha256", "some-sha256"))
+
+		for i, layer := range result.Layers {
+			Expect(layer.SBOM).NotTo(BeNil())
+
+			formats := layer.SBOM.Formats()
+			Expect(formats).To(HaveLen(3))
+
+			extensions := []string{}
+			for _, format := range formats {
+				extensions = append(extensions, format.Extension)
+			}
+			Expect(extensions).To(ConsistOf("cdx.json", "spdx.json", "syft.json"))
+
+			var cdx packit.SBOMFormat
+			for _, format := range formats {
+				if format.Extension == "cdx.json" {
+					cdx = format
+				}
+			}
+
+			content, err := io.ReadAll(cdx.Content)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(ContainSubstring(`"name": "erlang-otp"`))
+			Expect(string(content)).To(ContainSubstring(`"version": "28.1.1"`))
+			Expect(string(content)).To(ContainSubstring("download_url=https%3A%2F%2Fexample.com%2FOTP-28.1.1.tar.gz"))
+			Expect(string(content)).To(ContainSubstring("checksum=sha256%3Asome-sha256"))
+			Expect(string(content)).To(ContainSubstring("pkg:otp/stdlib@7.1"))
+
+			if i == 0 {
+				Expect(string(content)).To(ContainSubstring("pkg:otp/wx@2.5"))
+			} else {
+				Expect(string(content)).NotTo(ContainSubstring("pkg:otp/wx@2.5"))
+			}
+		}
+	})
+
+	it("normalises file metadata in both layers", func() {
+		installer.InstallCall.Stub = func(_, layerPath string) (string, error) {
+			Expect(os.MkdirAll(filepath.Join(layerPath, "bin"), 0700)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(layerPath, "bin", "erl"), []byte("erl"), 0700)).To(Succeed())
+			return "some-sha256", nil
+		}
+
+		_, err := build(buildContext)
+		Expect(err).NotTo(HaveOccurred())
+
+		for _, layer := range []string{"erlang", "erlang-launch"} {
+			info, err := os.Stat(filepath.Join(layersDir, layer, "bin", "erl"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode()).To(Equal(os.FileMode(0755)))
+			Expect(info.ModTime()).To(BeTemporally("==", erlang.DefaultSourceDateEpoch))
+		}
+	})
+
+	context("when SOURCE_DATE_EPOCH is set", func() {
+		it.Before(func() {
+			Expect(os.Setenv("SOURCE_DATE_EPOCH", "1700000000")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("SOURCE_DATE_EPOCH")).To(Succeed())
+		})
+
+		it("uses it as the build time", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			info, err := os.Stat(filepath.Join(layersDir, "erlang-launch", "provenance.intoto.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.ModTime()).To(BeTemporally("==", time.Unix(1700000000, 0)))
+
+			content, err := os.ReadFile(filepath.Join(layersDir, "erlang", "provenance.intoto.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(ContainSubstring(`"startedOn": "2023-11-14T22:13:20Z"`))
+
+			Expect(result.Layers[1].Metadata).To(HaveKeyWithValue("source-date-epoch", "1700000000"))
+		})
+	})
+
+	it("labels the image with the installed runtime", func() {
+		installer.BuildDownloadURLCall.Returns.String = "https://example.com/OTP-28.1.1.tar.gz"
+		installer.InstallCall.Stub = func(_, layerPath string) (string, error) {
+			Expect(os.MkdirAll(filepath.Join(layerPath, "erts-16.1.1", "bin"), os.ModePerm)).To(Succeed())
+			return "some-sha256", nil
+		}
+
+		result, err := build(buildContext)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(result.Launch.Labels).To(Equal(map[string]string{
+			"io.buildpacks.erlang.otp-version":  "28.1.1",
+			"io.buildpacks.erlang.erts-version": "16.1.1",
+			"io.buildpacks.erlang.arch":         "amd64",
+			"io.buildpacks.erlang.source":       "https://example.com/OTP-28.1.1.tar.gz",
+		}))
+	})
+
+	it("records the provenance of the installed runtime", func() {
+		timeStamp = time.Date(2025, time.October, 20, 12, 0, 0, 0, time.UTC)
+		buildContext.BuildpackInfo.ID = "some-buildpack-id"
+		installer.BuildDownloadURLCall.Returns.String = "https://example.com/OTP-28.1.1.tar.gz"
+		installer.InstallCall.Returns.Sha256 = "some-sha256"
+
+		_, err := build(buildContext)
+		Expect(err).NotTo(HaveOccurred())
+
+		content, err := os.ReadFile(filepath.Join(layersDir, "erlang", "provenance.intoto.json"))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(string(content)).To(MatchJSON(`{
+			"_type": "https://in-toto.io/Statement/v1",
+			"subject": [{"name": "erlang-otp-28.1.1.tar.gz", "uri": "https://example.com/OTP-28.1.1.tar.gz", "digest": {"sha256": "some-sha256"}}],
+			"predicateType": "https://slsa.dev/provenance/v1",
+			"predicate": {
+				"buildDefinition": {
+					"buildType": "https://github.com/SnakeDoc/erlang-cnb/provenance/v1",
+					"externalParameters": {
+						"version": "28.1.1",
+						"versionSource": "BP_ERLANG_VERSION",
+						"arch": "amd64",
+						"ubuntuVersion": "ubuntu-22.04"
+					},
+					"resolvedDependencies": [{"name": "erlang-otp-28.1.1.tar.gz", "uri": "https://example.com/OTP-28.1.1.tar.gz", "digest": {"sha256": "some-sha256"}}]
+				},
+				"runDetails": {
+					"builder": {"id": "some-buildpack-id", "version": {"some-buildpack-id": "0.0.1"}},
+					"metadata": {"startedOn": "1980-01-01T00:00:01Z"}
+				}
+			}
+		}`))
+
+		launched, err := os.ReadFile(filepath.Join(layersDir, "erlang-launch", "provenance.intoto.json"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(launched).To(Equal(content))
+
+		Expect(filepath.Join(layersDir, "erlang.provenance.intoto.json")).NotTo(BeAnExistingFile())
+	})
+
+	it("records the same provenance whenever the runtime is installed", func() {
+		timeStamp = time.Date(2025, time.October, 20, 12, 0, 0, 0, time.UTC)
+		_, err := build(buildContext)
+		Expect(err).NotTo(HaveOccurred())
+
+		first, err := os.ReadFile(filepath.Join(layersDir, "erlang-launch", "provenance.intoto.json"))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.RemoveAll(layersDir)).To(Succeed())
+		Expect(os.MkdirAll(layersDir, os.ModePerm)).To(Succeed())
+
+		timeStamp = time.Date(2025, time.October, 21, 8, 30, 0, 0, time.UTC)
+		_, err = build(buildContext)
+		Expect(err).NotTo(HaveOccurred())
+
+		second, err := os.ReadFile(filepath.Join(layersDir, "erlang-launch", "provenance.intoto.json"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(second).To(Equal(first))
+	})
+
+	context("when the resolved version has known vulnerabilities", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_ERLANG_VERSION", "27.3.2")).To(Succeed())
+		})
+
+		it("warns with the advisory ids and fixed versions", func() {
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buffer.String()).To(ContainSubstring("WARNING: Erlang 27.3.2 is affected by known vulnerabilities"))
+			Expect(buffer.String()).To(ContainSubstring("CVE-2025-32433"))
+			Expect(buffer.String()).To(ContainSubstring("(fixed in 27.3.3)"))
+		})
+
+		context("when BP_ERLANG_FAIL_ON_VULNERABLE is true", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_ERLANG_FAIL_ON_VULNERABLE", "true")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_ERLANG_FAIL_ON_VULNERABLE")).To(Succeed())
+			})
+
+			it("fails the build", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(ContainSubstring("refusing to install Erlang 27.3.2: affected by CVE-2025-32433")))
+				Expect(installer.InstallCall.CallCount).To(Equal(0))
+			})
+		})
+	})
+
+	context("when the version policy forbids the resolved version", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_ERLANG_ALLOWED_VERSIONS", ">=28.1.2")).To(Succeed())
+			versionLister.ListVersionsCall.Returns.StringSlice = []string{"28.1.1", "28.1.2", "28.2", "27.3.4"}
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_ERLANG_ALLOWED_VERSIONS")).To(Succeed())
+		})
+
+		it("fails with the broken rule and a permitted version", func() {
+			_, err := build(buildContext)
+			Expect(err).To(MatchError(ContainSubstring(`version policy does not permit Erlang 28.1.1: it does not match the allowed versions ">=28.1.2" from BP_ERLANG_ALLOWED_VERSIONS`)))
+			Expect(err).To(MatchError(ContainSubstring("the newest permitted version is 28.2")))
+			Expect(versionLister.ListVersionsCall.Receives.Arch).To(Equal("amd64"))
+			Expect(versionLister.ListVersionsCall.Receives.UbuntuVersion).To(Equal("ubuntu-22.04"))
+			Expect(installer.InstallCall.CallCount).To(Equal(0))
+		})
+
+		context("when the available versions cannot be listed", func() {
+			it.Before(func() {
+				versionLister.ListVersionsCall.Returns.Error = errors.New("network unreachable")
+			})
+
+			it("still fails the build", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(ContainSubstring("version policy does not permit Erlang 28.1.1")))
+				Expect(buffer.String()).To(ContainSubstring("Unable to list available Erlang versions: network unreachable"))
+			})
+		})
+	})
+
+	context("when a version policy binding is present", func() {
+		it.Before(func() {
+			buildContext.Platform.Path = "some-platform"
+			bindingResolver.ResolveCall.Returns.BindingSlice = []servicebindings.Binding{
+				{
+					Name: "otp-policy",
+					Type: "erlang-version-policy",
+					Entries: map[string]*servicebindings.Entry{
+						"denied-versions": servicebindings.NewWithValue([]byte("28.1\n")),
+					},
+				},
+			}
+		})
+
+		it("enforces its rules", func() {
+			_, err := build(buildContext)
+			Expect(bindingResolver.ResolveCall.Receives.Typ).To(Equal("erlang-version-policy"))
+			Expect(bindingResolver.ResolveCall.Receives.PlatformDir).To(Equal("some-platform"))
+			Expect(err).To(MatchError(ContainSubstring(`it matches the denied versions "28.1" from binding otp-policy (denied-versions)`)))
+		})
+	})
+
+	context("when the pinned version is past end of support", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_ERLANG_VERSION", "25.3.2.21")).To(Succeed())
+			timeStamp = time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)
+			versionLister.ListVersionsCall.Returns.StringSlice = []string{"25.3.2.21", "27.3.4", "28.1.1"}
+		})
+
+		it("warns with the support status and newest supported patch", func() {
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buffer.String()).To(ContainSubstring("WARNING: Erlang 25.3.2.21 is past end of support"))
+			Expect(buffer.String()).To(ContainSubstring("Pinned through BP_ERLANG_VERSION"))
+			Expect(buffer.String()).To(ContainSubstring("OTP 25 stopped receiving updates on 2025-05-21, when OTP 28 was released"))
+			Expect(buffer.String()).To(ContainSubstring("Newest supported patch: 28.1.1"))
+		})
+
+		context("when the build plan also carries a version from .tool-versions", func() {
+			it.Before(func() {
+				buildContext.Plan.Entries = []packit.BuildpackPlanEntry{
+					{
+						Name: "erlang",
+						Metadata: map[string]interface{}{
+							"version":        "24.3.4.17",
+							"version-source": ".tool-versions",
+						},
+					},
+				}
+			})
+
+			it("warns about the version it installs", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(installer.BuildDownloadURLCall.Receives.Version).To(Equal("25.3.2.21"))
+				Expect(buffer.String()).To(ContainSubstring("WARNING: Erlang 25.3.2.21 is past end of support"))
+				Expect(buffer.String()).NotTo(ContainSubstring("24.3.4.17"))
+			})
+		})
+
+		context("when BP_ERLANG_FAIL_ON_UNSUPPORTED is true", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_ERLANG_FAIL_ON_UNSUPPORTED", "true")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_ERLANG_FAIL_ON_UNSUPPORTED")).To(Succeed())
+			})
+
+			it("fails the build", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("refusing to install Erlang 25.3.2.21: OTP 25 is past end of support and BP_ERLANG_FAIL_ON_UNSUPPORTED is set"))
+				Expect(installer.InstallCall.CallCount).To(Equal(0))
+			})
+		})
+	})
+
+	context("when .tool-versions pins a version past end of support", func() {
+		var transport http.RoundTripper
+
+		it.Before(func() {
+			Expect(os.Unsetenv("BP_ERLANG_VERSION")).To(Succeed())
+			timeStamp = time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)
+			versionLister.ListVersionsCall.Returns.StringSlice = []string{"24.3.4.17", "27.3.4", "28.1.1"}
+
+			// serve builds.txt without reaching builds.hex.pm
+			transport = http.DefaultTransport
+			http.DefaultTransport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader("OTP-28.1.1 hash1 2025-10-20T15:23:31Z\n")),
+					Request:    req,
+				}, nil
+			})
+
+			buildContext.Plan.Entries = []packit.BuildpackPlanEntry{
+				{
+					Name: "erlang",
+					Metadata: map[string]interface{}{
+						"version":        "24.3.4.17",
+						"version-source": ".tool-versions",
+					},
+				},
+			}
+		})
+
+		it.After(func() {
+			http.DefaultTransport = transport
+		})
+
+		it("warns that the pin is unsupported and ignored", func() {
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(installer.BuildDownloadURLCall.Receives.Version).To(Equal("28.1.1"))
+			Expect(buffer.String()).To(ContainSubstring("WARNING: ignoring Erlang 24.3.4.17 pinned in .tool-versions"))
+			Expect(buffer.String()).To(ContainSubstring("Only BP_ERLANG_VERSION selects the installed version; installing 28.1.1"))
+			Expect(buffer.String()).To(ContainSubstring("WARNING: Erlang 24.3.4.17 is past end of support"))
+			Expect(buffer.String()).To(ContainSubstring("Pinned through .tool-versions"))
+			Expect(buffer.String()).To(ContainSubstring("Newest supported patch: 28.1.1"))
+		})
+
+		it("records builds.txt as the version source", func() {
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			content, err := os.ReadFile(filepath.Join(layersDir, "erlang", erlang.ProvenanceFile))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(ContainSubstring(`"versionSource": "builds.txt"`))
+			Expect(string(content)).NotTo(ContainSubstring(".tool-versions"))
+		})
+
+		context("when BP_ERLANG_FAIL_ON_UNSUPPORTED is true", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_ERLANG_FAIL_ON_UNSUPPORTED", "true")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_ERLANG_FAIL_ON_UNSUPPORTED")).To(Succeed())
+			})
+
+			it("fails the build", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("refusing to build with Erlang 24.3.4.17 pinned in .tool-versions: OTP 24 is past end of support and BP_ERLANG_FAIL_ON_UNSUPPORTED is set"))
+				Expect(installer.InstallCall.CallCount).To(Equal(0))
+			})
+		})
+	})
+
+	context("when the plan requires rebar3", func() {
+		var escriptSHA256 string
+
+		it.Before(func() {
+			sum := sha256.Sum256([]byte("rebar3"))
+			escriptSHA256 = hex.EncodeToString(sum[:])
+
+			buildContext.Plan.Entries = []packit.BuildpackPlanEntry{
+				{
+					Name: "rebar3",
+					Metadata: map[string]interface{}{
+						"version":        "3.25.1",
+						"version-source": ".tool-versions",
+					},
+				},
+			}
+
+			rebar3Installer.ResolveReleaseCall.Returns.Rebar3Release = erlang.Rebar3Release{
+				Version: "3.25.1",
+				URL:     "https://example.com/rebar3",
+				SHA256:  escriptSHA256,
+			}
+			rebar3Installer.InstallCall.Stub = func(_ erlang.Rebar3Release, layerPath string) error {
+				Expect(os.MkdirAll(filepath.Join(layerPath, "bin"), os.ModePerm)).To(Succeed())
+				return os.WriteFile(filepath.Join(layerPath, "bin", "rebar3"), []byte("rebar3"), 0755)
+			}
+		})
+
+		it("installs rebar3 into its own build layer", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers).To(HaveLen(4))
+			layer := result.Layers[2]
+
+			Expect(layer.Name).To(Equal("rebar3"))
+			Expect(layer.Build).To(BeTrue())
+			Expect(layer.Cache).To(BeTrue())
+			Expect(layer.Launch).To(BeFalse())
+			Expect(layer.BuildEnv).To(HaveKeyWithValue("PATH.prepend", filepath.Join(layersDir, "rebar3", "bin")))
+			Expect(layer.Metadata).To(HaveKeyWithValue("version", "3.25.1"))
+			Expect(layer.Metadata).To(HaveKeyWithValue("sha256", escriptSHA256))
+			Expect(layer.BuildEnv).To(HaveKeyWithValue("REBAR_CACHE_DIR.default", filepath.Join(layersDir, "rebar3-cache", "rebar3")))
+			Expect(layer.BuildEnv).To(HaveKeyWithValue("HEX_HOME.default", filepath.Join(layersDir, "rebar3-cache", "hex")))
+
+			cacheLayer := result.Layers[3]
+
+			Expect(cacheLayer.Name).To(Equal("rebar3-cache"))
+			Expect(cacheLayer.Cache).To(BeTrue())
+			Expect(cacheLayer.Build).To(BeFalse())
+			Expect(cacheLayer.Launch).To(BeFalse())
+			Expect(cacheLayer.Metadata).To(HaveKeyWithValue("rebar-lock-sha256", ""))
+			Expect(filepath.Join(layersDir, "rebar3-cache", "rebar3")).To(BeADirectory())
+			Expect(filepath.Join(layersDir, "rebar3-cache", "hex")).To(BeADirectory())
+
+			Expect(rebar3Installer.ResolveReleaseCall.Receives.Version).To(Equal("3.25.1"))
+			Expect(rebar3Installer.InstallCall.Receives.LayerPath).To(Equal(filepath.Join(layersDir, "rebar3")))
+
+			Expect(buffer.String()).To(ContainSubstring("Using rebar3 version: 3.25.1 (from .tool-versions)"))
+			Expect(buffer.String()).To(ContainSubstring("Installing rebar3 3.25.1"))
+		})
+
+		context("when BP_REBAR3_VERSION is set", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_REBAR3_VERSION", "3.24.0")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_REBAR3_VERSION")).To(Succeed())
+			})
+
+			it("takes precedence over the plan", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rebar3Installer.ResolveReleaseCall.Receives.Version).To(Equal("3.24.0"))
+			})
+		})
+
+		context("when the pinned version is cached", func() {
+			it.Before(func() {
+				Expect(os.MkdirAll(filepath.Join(layersDir, "rebar3", "bin"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(layersDir, "rebar3", "bin", "rebar3"), []byte("rebar3"), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(layersDir, "rebar3.toml"), []byte(fmt.Sprintf(`
+					[metadata]
+					version = "3.25.1"
+					sha256 = %q
+					source-date-epoch = "315532801"
+				`, escriptSHA256)), 0644)).To(Succeed())
+			})
+
+			it("reuses the layer without contacting GitHub", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(rebar3Installer.ResolveReleaseCall.CallCount).To(Equal(0))
+				Expect(rebar3Installer.InstallCall.CallCount).To(Equal(0))
+				Expect(buffer.String()).To(ContainSubstring("Reusing cached layer " + filepath.Join(layersDir, "rebar3")))
+			})
+
+			context("when the cached escript has been modified", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(layersDir, "rebar3", "bin", "rebar3"), []byte("tampered"), 0755)).To(Succeed())
+				})
+
+				it("reinstalls rebar3", func() {
+					_, err := build(buildContext)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(rebar3Installer.InstallCall.CallCount).To(Equal(1))
+				})
+			})
+		})
+
+		context("when the package cache matches rebar.lock", func() {
+			it.Before(func() {
+				lock := []byte(`{"1.2.0", []}.`)
+				Expect(os.WriteFile(filepath.Join(workingDir, "rebar.lock"), lock, 0644)).To(Succeed())
+
+				sum := sha256.Sum256(lock)
+				Expect(os.MkdirAll(filepath.Join(layersDir, "rebar3-cache", "hex"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(layersDir, "rebar3-cache", "hex", "cowboy-2.10.0.tar"), []byte("tar"), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(layersDir, "rebar3-cache.toml"), []byte(fmt.Sprintf(`
+					[metadata]
+					rebar-lock-sha256 = %q
+				`, hex.EncodeToString(sum[:]))), 0644)).To(Succeed())
+			})
+
+			it("reuses the cache", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(filepath.Join(layersDir, "rebar3-cache", "hex", "cowboy-2.10.0.tar")).To(BeARegularFile())
+				Expect(buffer.String()).To(ContainSubstring("Reusing cached layer " + filepath.Join(layersDir, "rebar3-cache")))
+			})
+
+			context("when rebar.lock changes", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "rebar.lock"), []byte(`{"1.2.0", [{<<"cowboy">>,{pkg,<<"cowboy">>,<<"2.12.0">>},0}]}.`), 0644)).To(Succeed())
+				})
+
+				it("discards the cache", func() {
+					result, err := build(buildContext)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(filepath.Join(layersDir, "rebar3-cache", "hex", "cowboy-2.10.0.tar")).NotTo(BeAnExistingFile())
+					Expect(filepath.Join(layersDir, "rebar3-cache", "hex")).To(BeADirectory())
+					Expect(result.Layers[3].Metadata).NotTo(HaveKeyWithValue("rebar-lock-sha256", ""))
+					Expect(buffer.String()).To(ContainSubstring("Discarding package cache: rebar.lock has changed"))
+				})
+			})
+		})
+
+		context("when the installed OTP is too old for rebar3", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_ERLANG_VERSION", "25.3.2.21")).To(Succeed())
+			})
+
+			it("fails the build", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("rebar3 3.25 requires OTP 26 or newer, but OTP 25.3.2.21 is installed"))
+				Expect(rebar3Installer.InstallCall.CallCount).To(Equal(0))
+			})
+		})
+
+		context("when rebar3 has not been tested with the installed OTP", func() {
+			it.Before(func() {
+				rebar3Installer.ResolveReleaseCall.Returns.Rebar3Release.Version = "3.24.0"
+			})
+
+			it("warns", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(buffer.String()).To(ContainSubstring("WARNING: rebar3 3.24.0 has not been tested with OTP 28.1.1"))
+				Expect(buffer.String()).To(ContainSubstring("rebar3 3.24 supports OTP 25 to 27"))
+			})
+		})
+
+		context("when resolving rebar3 fails", func() {
+			it.Before(func() {
+				rebar3Installer.ResolveReleaseCall.Returns.Error = errors.New("rate limited")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("failed to resolve rebar3 version: rate limited"))
+			})
+		})
+
+		context("when installing rebar3 fails", func() {
+			it.Before(func() {
+				rebar3Installer.InstallCall.Stub = nil
+				rebar3Installer.InstallCall.Returns.Error = errors.New("checksum mismatch")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("failed to install rebar3 3.25.1: checksum mismatch"))
+			})
+		})
+
+		context("when rebar.config has a relx section", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "rebar.config"), []byte(`
+{relx, [{release, {myapp, "0.1.0"}, [myapp, sasl]}]}.
+`), 0644)).To(Succeed())
+
+				rebar3.ExecuteCall.Stub = func(execution pexec.Execution) error {
+					profile := execution.Args[1]
+					name := execution.Args[len(execution.Args)-1]
+					releaseDir := filepath.Join(execution.Dir, "_build", profile, "rel", name)
+					Expect(os.MkdirAll(filepath.Join(releaseDir, "bin"), os.ModePerm)).To(Succeed())
+					return os.WriteFile(filepath.Join(releaseDir, "bin", name), []byte("#!/bin/sh"), 0755)
+				}
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_REBAR3_PROFILE")).To(Succeed())
+				Expect(os.Unsetenv("BP_ERLANG_RELEASE_NAME")).To(Succeed())
+			})
+
+			it("builds the release into a launch layer", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(rebar3.ExecuteCall.Receives.Execution.Args).To(Equal([]string{"as", "prod", "release", "-n", "myapp"}))
+				Expect(rebar3.ExecuteCall.Receives.Execution.Dir).To(Equal(workingDir))
+				Expect(rebar3.ExecuteCall.Receives.Execution.Env).To(ContainElement(HavePrefix("PATH=" + filepath.Join(layersDir, "rebar3", "bin") + ":" + filepath.Join(layersDir, "erlang", "bin") + ":")))
+				Expect(rebar3.ExecuteCall.Receives.Execution.Env).To(ContainElement("REBAR_CACHE_DIR=" + filepath.Join(layersDir, "rebar3-cache", "rebar3")))
+				Expect(rebar3.ExecuteCall.Receives.Execution.Env).To(ContainElement("HEX_HOME=" + filepath.Join(layersDir, "rebar3-cache", "hex")))
+
+				Expect(result.Layers).To(HaveLen(6))
+
+				depsLayer := result.Layers[4]
+				Expect(depsLayer.Name).To(Equal("rebar3-deps"))
+				Expect(depsLayer.Cache).To(BeTrue())
+				Expect(depsLayer.Build).To(BeFalse())
+				Expect(depsLayer.Launch).To(BeFalse())
+				Expect(depsLayer.Metadata).To(HaveKeyWithValue("version", "28.1.1"))
+				Expect(depsLayer.Metadata).To(HaveKeyWithValue("profile", "prod"))
+
+				layer := result.Layers[5]
+
+				Expect(layer.Name).To(Equal("release"))
+				Expect(layer.Launch).To(BeTrue())
+				Expect(layer.Build).To(BeFalse())
+				Expect(layer.Cache).To(BeFalse())
+				Expect(layer.Metadata).To(HaveKeyWithValue("release", "myapp"))
+				Expect(layer.Metadata).To(HaveKeyWithValue("profile", "prod"))
+				Expect(layer.Metadata).To(HaveKeyWithValue("version", "0.1.0"))
+				Expect(filepath.Join(layersDir, "release", "bin", "myapp")).To(BeARegularFile())
+
+				command := []string{filepath.Join(layersDir, "release", "bin", "myapp"), "foreground"}
+				Expect(result.Launch.DirectProcesses).To(Equal([]packit.DirectProcess{
+					{Type: "web", Command: command, Default: true},
+					{Type: "release", Command: command},
+				}))
+
+				Expect(buffer.String()).To(ContainSubstring("Building release myapp with rebar3 as prod"))
+			})
+
+			context("when rebar.lock locks dependencies", func() {
+				var lockDigest string
+
+				it.Before(func() {
+					lock := []byte(`{"1.2.0",
+[{<<"cowboy">>,{pkg,<<"cowboy">>,<<"2.10.0">>},0},
+ {<<"ranch">>,{pkg,<<"ranch">>,<<"1.8.0">>},1}]}.
+`)
+					Expect(os.WriteFile(filepath.Join(workingDir, "rebar.lock"), lock, 0644)).To(Succeed())
+					sum := sha256.Sum256(lock)
+					lockDigest = hex.EncodeToString(sum[:])
+
+					stub := rebar3.ExecuteCall.Stub
+					rebar3.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						libDir := filepath.Join(execution.Dir, "_build", "prod", "lib")
+						for _, app := range []string{"cowboy", "ranch", "myapp"} {
+							Expect(os.MkdirAll(filepath.Join(libDir, app, "ebin"), os.ModePerm)).To(Succeed())
+							Expect(os.WriteFile(filepath.Join(libDir, app, "ebin", app+".beam"), []byte("compiled"), 0644)).To(Succeed())
+						}
+						return stub(execution)
+					}
+				})
+
+				it("caches the compiled dependencies but not the app", func() {
+					result, err := build(buildContext)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(result.Layers[4].Metadata).To(HaveKeyWithValue("rebar-lock-sha256", lockDigest))
+					Expect(filepath.Join(layersDir, "rebar3-deps", "lib", "cowboy", "ebin", "cowboy.beam")).To(BeARegularFile())
+					Expect(filepath.Join(layersDir, "rebar3-deps", "lib", "ranch", "ebin", "ranch.beam")).To(BeARegularFile())
+					Expect(filepath.Join(layersDir, "rebar3-deps", "lib", "myapp")).NotTo(BeAnExistingFile())
+					Expect(buffer.String()).To(ContainSubstring("Cached 2 compiled dependencies"))
+				})
+
+				context("when the dependency cache matches", func() {
+					it.Before(func() {
+						Expect(os.MkdirAll(filepath.Join(layersDir, "rebar3-deps", "lib", "cowboy", "ebin"), os.ModePerm)).To(Succeed())
+						Expect(os.WriteFile(filepath.Join(layersDir, "rebar3-deps", "lib", "cowboy", "ebin", "cowboy.beam"), []byte("cached"), 0644)).To(Succeed())
+						Expect(os.WriteFile(filepath.Join(layersDir, "rebar3-deps.toml"), []byte(fmt.Sprintf(`
+							[metadata]
+							rebar-lock-sha256 = %q
+							version = "28.1.1"
+							profile = "prod"
+						`, lockDigest)), 0644)).To(Succeed())
+
+						rebar3.ExecuteCall.Stub = func(execution pexec.Execution) error {
+							releaseDir := filepath.Join(execution.Dir, "_build", "prod", "rel", "myapp")
+							Expect(os.MkdirAll(filepath.Join(releaseDir, "bin"), os.ModePerm)).To(Succeed())
+							return os.WriteFile(filepath.Join(releaseDir, "bin", "myapp"), []byte("#!/bin/sh"), 0755)
+						}
+					})
+
+					it("restores the dependencies before building", func() {
+						_, err := build(buildContext)
+						Expect(err).NotTo(HaveOccurred())
+
+						content, err := os.ReadFile(filepath.Join(workingDir, "_build", "prod", "lib", "cowboy", "ebin", "cowboy.beam"))
+						Expect(err).NotTo(HaveOccurred())
+						Expect(string(content)).To(Equal("cached"))
+						Expect(buffer.String()).To(ContainSubstring("Restored 1 compiled dependencies from " + filepath.Join(layersDir, "rebar3-deps")))
+					})
+				})
+
+				context("when the dependency cache was built with another OTP patch", func() {
+					it.Before(func() {
+						Expect(os.MkdirAll(filepath.Join(layersDir, "rebar3-deps", "lib", "cowboy", "ebin"), os.ModePerm)).To(Succeed())
+						Expect(os.WriteFile(filepath.Join(layersDir, "rebar3-deps", "lib", "cowboy", "ebin", "cowboy.beam"), []byte("stale"), 0644)).To(Succeed())
+						Expect(os.WriteFile(filepath.Join(layersDir, "rebar3-deps.toml"), []byte(fmt.Sprintf(`
+							[metadata]
+							rebar-lock-sha256 = %q
+							version = "28.1"
+							profile = "prod"
+						`, lockDigest)), 0644)).To(Succeed())
+					})
+
+					it("rebuilds everything", func() {
+						_, err := build(buildContext)
+						Expect(err).NotTo(HaveOccurred())
+
+						content, err := os.ReadFile(filepath.Join(layersDir, "rebar3-deps", "lib", "cowboy", "ebin", "cowboy.beam"))
+						Expect(err).NotTo(HaveOccurred())
+						Expect(string(content)).To(Equal("compiled"))
+						Expect(buffer.String()).To(ContainSubstring("Discarding dependency cache: built with OTP 28.1"))
+						Expect(buffer.String()).NotTo(ContainSubstring("Restored"))
+					})
+				})
+
+				context("when rebar.lock has changed", func() {
+					it.Before(func() {
+						Expect(os.MkdirAll(filepath.Join(layersDir, "rebar3-deps", "lib"), os.ModePerm)).To(Succeed())
+						Expect(os.WriteFile(filepath.Join(layersDir, "rebar3-deps.toml"), []byte(`
+							[metadata]
+							rebar-lock-sha256 = "0000"
+							version = "28.1.1"
+							profile = "prod"
+						`), 0644)).To(Succeed())
+					})
+
+					it("discards the cache", func() {
+						_, err := build(buildContext)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(buffer.String()).To(ContainSubstring("Discarding dependency cache: rebar.lock has changed"))
+					})
+				})
+			})
+
+			context("when hex bindings are present", func() {
+				var globalConfig string
+
+				it.Before(func() {
+					bindingResolver.ResolveCall.Stub = func(typ, _, _ string) ([]servicebindings.Binding, error) {
+						if typ != "hex" {
+							return nil, nil
+						}
+						return []servicebindings.Binding{
+							{
+								Name: "acme",
+								Entries: map[string]*servicebindings.Entry{
+									"organization": servicebindings.NewWithValue([]byte("acme")),
+									"api-key":      servicebindings.NewWithValue([]byte("super-secret-key")),
+									"mirror":       servicebindings.NewWithValue([]byte("https://hex.example.com")),
+								},
+							},
+						}, nil
+					}
+
+					stub := rebar3.ExecuteCall.Stub
+					rebar3.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						for _, variable := range execution.Env {
+							if dir, ok := strings.CutPrefix(variable, "REBAR_GLOBAL_CONFIG_DIR="); ok {
+								globalConfig = filepath.Join(dir, ".config", "rebar3", "rebar.config")
+								content, err := os.ReadFile(globalConfig)
+								Expect(err).NotTo(HaveOccurred())
+								Expect(string(content)).To(ContainSubstring(`#{name => <<"hexpm:acme">>, repo_key => <<"super-secret-key">>}`))
+							}
+						}
+						return stub(execution)
+					}
+				})
+
+				it("configures rebar3 for the build only", func() {
+					result, err := build(buildContext)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(rebar3.ExecuteCall.Receives.Execution.Env).To(ContainElements(
+						"HEX_MIRROR=https://hex.example.com",
+						"HEX_CDN=https://hex.example.com",
+					))
+					Expect(globalConfig).NotTo(BeEmpty())
+					Expect(globalConfig).NotTo(BeAnExistingFile())
+
+					for _, layer := range result.Layers {
+						Expect(fmt.Sprint(layer.BuildEnv, layer.LaunchEnv, layer.SharedEnv)).NotTo(ContainSubstring("super-secret-key"))
+					}
+
+					Expect(buffer.String()).To(ContainSubstring("Configuring Hex from bindings: acme"))
+					Expect(buffer.String()).To(ContainSubstring("Organisation acme"))
+					Expect(buffer.String()).NotTo(ContainSubstring("super-secret-key"))
+				})
+			})
+
+			context("when BP_REBAR3_PROFILE is set", func() {
+				it.Before(func() {
+					Expect(os.Setenv("BP_REBAR3_PROFILE", "staging")).To(Succeed())
+				})
+
+				it("builds the release with that profile", func() {
+					_, err := build(buildContext)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(rebar3.ExecuteCall.Receives.Execution.Args).To(Equal([]string{"as", "staging", "release", "-n", "myapp"}))
+				})
+			})
+
+			context("when rebar.config declares several releases", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "rebar.config"), []byte(`
+{relx, [{release, {myapp, "0.1.0"}, [myapp]},
+        {release, {admin, "0.2.0"}, [admin]}]}.
+`), 0644)).To(Succeed())
+				})
+
+				it("requires BP_ERLANG_RELEASE_NAME", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("rebar.config declares several releases (myapp, admin): set BP_ERLANG_RELEASE_NAME to choose one"))
+					Expect(rebar3.ExecuteCall.CallCount).To(Equal(0))
+				})
+
+				context("when BP_ERLANG_RELEASE_NAME is set", func() {
+					it.Before(func() {
+						Expect(os.Setenv("BP_ERLANG_RELEASE_NAME", "admin")).To(Succeed())
+					})
+
+					it("builds the named release", func() {
+						result, err := build(buildContext)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(rebar3.ExecuteCall.Receives.Execution.Args).To(Equal([]string{"as", "prod", "release", "-n", "admin"}))
+						Expect(result.Launch.DirectProcesses[0].Command).To(Equal([]string{filepath.Join(layersDir, "release", "bin", "admin"), "foreground"}))
+					})
+				})
+			})
+
+			context("when the release build fails", func() {
+				it.Before(func() {
+					rebar3.ExecuteCall.Stub = nil
+					rebar3.ExecuteCall.Returns.Err = errors.New("exit status 1")
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("failed to build release myapp: exit status 1"))
+				})
+			})
+		})
+
+		context("when BP_ERLANG_RUN_DIALYZER is true", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_ERLANG_RUN_DIALYZER", "true")).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, "rebar.config"), []byte(`{dialyzer, [{plt_extra_apps, [ssl]}]}.`), 0644)).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_ERLANG_RUN_DIALYZER")).To(Succeed())
+				Expect(os.Unsetenv("BP_ERLANG_DIALYZER_STRICT")).To(Succeed())
+			})
+
+			it("runs dialyzer with the PLTs in a cache layer", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				pltDir := filepath.Join(layersDir, "dialyzer-plt")
+				Expect(rebar3.ExecuteCall.Receives.Execution.Args).To(Equal([]string{"dialyzer", "--base-plt-location", pltDir, "--plt-location", pltDir}))
+				Expect(rebar3.ExecuteCall.Receives.Execution.Dir).To(Equal(workingDir))
+				Expect(rebar3.ExecuteCall.Receives.Execution.Env).To(ContainElement("REBAR_CACHE_DIR=" + filepath.Join(layersDir, "rebar3-cache", "rebar3")))
+
+				Expect(result.Layers).To(HaveLen(5))
+				layer := result.Layers[4]
+				Expect(layer.Name).To(Equal("dialyzer-plt"))
+				Expect(layer.Cache).To(BeTrue())
+				Expect(layer.Build).To(BeFalse())
+				Expect(layer.Launch).To(BeFalse())
+				Expect(layer.Metadata).To(HaveKeyWithValue("version", "28.1.1"))
+				Expect(layer.Metadata).To(HaveKeyWithValue("plt-apps", "crypto,erts,kernel,ssl,stdlib"))
+
+				Expect(buffer.String()).To(ContainSubstring("PLT applications: crypto, erts, kernel, ssl, stdlib"))
+			})
+
+			context("when the PLTs were built for the same OTP and applications", func() {
+				it.Before(func() {
+					Expect(os.MkdirAll(filepath.Join(layersDir, "dialyzer-plt"), os.ModePerm)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(layersDir, "dialyzer-plt", "rebar3_28.1.1_plt"), []byte("plt"), 0644)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(layersDir, "dialyzer-plt.toml"), []byte(`
+						[metadata]
+						version = "28.1.1"
+						plt-apps = "crypto,erts,kernel,ssl,stdlib"
+					`), 0644)).To(Succeed())
+				})
+
+				it("reuses them", func() {
+					_, err := build(buildContext)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(filepath.Join(layersDir, "dialyzer-plt", "rebar3_28.1.1_plt")).To(BeARegularFile())
+					Expect(buffer.String()).To(ContainSubstring("Reusing cached layer " + filepath.Join(layersDir, "dialyzer-plt")))
+				})
+			})
+
+			context("when the PLTs were built for another OTP version", func() {
+				it.Before(func() {
+					Expect(os.MkdirAll(filepath.Join(layersDir, "dialyzer-plt"), os.ModePerm)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(layersDir, "dialyzer-plt", "rebar3_28.1_plt"), []byte("plt"), 0644)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(layersDir, "dialyzer-plt.toml"), []byte(`
+						[metadata]
+						version = "28.1"
+						plt-apps = "crypto,erts,kernel,ssl,stdlib"
+					`), 0644)).To(Succeed())
+				})
+
+				it("discards them", func() {
+					_, err := build(buildContext)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(filepath.Join(layersDir, "dialyzer-plt", "rebar3_28.1_plt")).NotTo(BeAnExistingFile())
+					Expect(buffer.String()).To(ContainSubstring("Discarding PLTs built for OTP 28.1 with crypto,erts,kernel,ssl,stdlib"))
+				})
+			})
+
+			context("when dialyzer reports warnings", func() {
+				it.Before(func() {
+					rebar3.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						fmt.Fprintln(execution.Stdout, "===> Warnings occurred running dialyzer: 2")
+						return errors.New("exit status 1")
+					}
+				})
+
+				it("warns and continues", func() {
+					_, err := build(buildContext)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(buffer.String()).To(ContainSubstring("WARNING: dialyzer reported warnings"))
+				})
+
+				context("when BP_ERLANG_DIALYZER_STRICT is true", func() {
+					it.Before(func() {
+						Expect(os.Setenv("BP_ERLANG_DIALYZER_STRICT", "true")).To(Succeed())
+					})
+
+					it("fails the build", func() {
+						_, err := build(buildContext)
+						Expect(err).To(MatchError("dialyzer reported warnings and BP_ERLANG_DIALYZER_STRICT is set"))
+					})
+				})
+			})
+
+			context("when dialyzer fails to run", func() {
+				it.Before(func() {
+					rebar3.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						fmt.Fprintln(execution.Stdout, "===> Error in dialyzing apps: Analysis failed")
+						return errors.New("exit status 1")
+					}
+				})
+
+				it("fails the build", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("failed to run dialyzer: exit status 1"))
+				})
+			})
+		})
+
+		context("when BP_ERLANG_RUN_TESTS is set", func() {
+			var executions []pexec.Execution
+
+			it.Before(func() {
+				Expect(os.Setenv("BP_ERLANG_RUN_TESTS", "eunit,ct")).To(Succeed())
+
+				executions = nil
+				rebar3.ExecuteCall.Stub = func(execution pexec.Execution) error {
+					executions = append(executions, execution)
+					if execution.Args[0] == "eunit" {
+						return os.WriteFile(filepath.Join(workingDir, "TEST-http_tests.xml"), []byte(`<testsuite><testcase classname="http_tests" name="parse_test/0"/></testsuite>`), 0644)
+					}
+					return nil
+				}
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_ERLANG_RUN_TESTS")).To(Succeed())
+			})
+
+			it("runs the test tasks and keeps their reports in a build layer", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				reportsDir := filepath.Join(layersDir, "test-reports")
+				Expect(executions).To(HaveLen(2))
+				Expect(executions[0].Args).To(Equal([]string{"eunit"}))
+				Expect(executions[0].Dir).To(Equal(workingDir))
+				Expect(executions[1].Args).To(Equal([]string{"ct", "--logdir", filepath.Join(reportsDir, "ct")}))
+				Expect(executions[1].Env).To(ContainElement("REBAR_CACHE_DIR=" + filepath.Join(layersDir, "rebar3-cache", "rebar3")))
+
+				Expect(result.Layers).To(HaveLen(5))
+				layer := result.Layers[4]
+				Expect(layer.Name).To(Equal("test-reports"))
+				Expect(layer.Build).To(BeTrue())
+				Expect(layer.Launch).To(BeFalse())
+				Expect(layer.Cache).To(BeFalse())
+
+				Expect(filepath.Join(reportsDir, "eunit", "TEST-http_tests.xml")).To(BeARegularFile())
+				Expect(buffer.String()).To(ContainSubstring("Running rebar3 eunit"))
+				Expect(buffer.String()).To(ContainSubstring("Running rebar3 ct"))
+			})
+
+			context("when tests fail", func() {
+				it.Before(func() {
+					rebar3.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						executions = append(executions, execution)
+						switch execution.Args[0] {
+						case "eunit":
+							err := os.WriteFile(filepath.Join(workingDir, "TEST-http_tests.xml"), []byte(`<testsuite>
+  <testcase classname="http_tests" name="parse_test/0"><failure>bad</failure></testcase>
+  <testcase classname="http_tests" name="get_test/0"/>
+</testsuite>`), 0644)
+							if err != nil {
+								return err
+							}
+						case "ct":
+							fmt.Fprintln(execution.Stdout, "%%% api_SUITE ==> get_root: FAILED")
+						}
+						return errors.New("exit status 1")
+					}
+				})
+
+				it("runs every task and fails with a summary of the failed cases", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("rebar3 eunit and ct failed: 2 failed cases: http_tests:parse_test/0, api_SUITE:get_root"))
+					Expect(executions).To(HaveLen(2))
+				})
+			})
+
+			context("when a test task is not supported", func() {
+				it.Before(func() {
+					Expect(os.Setenv("BP_ERLANG_RUN_TESTS", "proper")).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError(ContainSubstring(`unsupported test task "proper"`)))
+					Expect(executions).To(BeEmpty())
+				})
+			})
+		})
+
+		context("when rebar.config has no relx section", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "rebar.config"), []byte("{deps, []}."), 0644)).To(Succeed())
+			})
+
+			it("does not build a release", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rebar3.ExecuteCall.CallCount).To(Equal(0))
+				Expect(result.Layers).To(HaveLen(4))
+				Expect(result.Launch.DirectProcesses).To(BeEmpty())
+			})
+		})
+	})
+
+	context("when the plan requires erlang.mk", func() {
+		var depsDigest string
+
+		it.Before(func() {
+			buildContext.Plan.Entries = []packit.BuildpackPlanEntry{
+				{Name: "erlang.mk"},
+			}
+
+			Expect(os.WriteFile(filepath.Join(workingDir, "relx.config"), []byte(`{release, {hello, "1.0.0"}, [hello, sasl]}.`), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "Makefile"), []byte("PROJECT = hello\nDEPS = cowboy\ndep_cowboy = hex 2.10.0\ninclude erlang.mk\n"), 0644)).To(Succeed())
+
+			var err error
+			depsDigest, err = erlang.ErlangMkDepsDigest(workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			gnuMake.ExecuteCall.Stub = func(execution pexec.Execution) error {
+				releaseDir := filepath.Join(execution.Dir, "_rel", "hello")
+				Expect(os.MkdirAll(filepath.Join(releaseDir, "bin"), os.ModePerm)).To(Succeed())
+				return os.WriteFile(filepath.Join(releaseDir, "bin", "hello"), []byte("#!/bin/sh"), 0755)
+			}
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_ERLANG_MK_TARGETS")).To(Succeed())
+		})
+
+		it("runs make rel and launches the release", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			execution := gnuMake.ExecuteCall.Receives.Execution
+			Expect(execution.Args).To(Equal([]string{"rel"}))
+			Expect(execution.Dir).To(Equal(workingDir))
+			Expect(execution.Env).To(ContainElement(HavePrefix("PATH=" + filepath.Join(layersDir, "erlang", "bin") + ":")))
+			Expect(execution.Env).To(ContainElement("ERLANG_MK_TMP=" + filepath.Join(layersDir, "erlang-mk-cache", "tmp")))
+			Expect(execution.Env).To(ContainElement("DEPS_DIR=" + filepath.Join(layersDir, "erlang-mk-cache", "deps")))
+
+			Expect(result.Layers).To(HaveLen(4))
+
+			cacheLayer := result.Layers[2]
+			Expect(cacheLayer.Name).To(Equal("erlang-mk-cache"))
+			Expect(cacheLayer.Cache).To(BeTrue())
+			Expect(cacheLayer.Build).To(BeFalse())
+			Expect(cacheLayer.Launch).To(BeFalse())
+			Expect(cacheLayer.Metadata).To(HaveKeyWithValue("version", "28.1.1"))
+			Expect(cacheLayer.Metadata).To(HaveKeyWithValue("deps-spec-sha256", depsDigest))
+
+			releaseLayer := result.Layers[3]
+			Expect(releaseLayer.Name).To(Equal("release"))
+			Expect(releaseLayer.Launch).To(BeTrue())
+			Expect(releaseLayer.Metadata).To(HaveKeyWithValue("release", "hello"))
+			Expect(releaseLayer.Metadata).To(HaveKeyWithValue("version", "1.0.0"))
+			Expect(filepath.Join(layersDir, "release", "bin", "hello")).To(BeARegularFile())
+
+			command := []string{filepath.Join(layersDir, "release", "bin", "hello"), "foreground"}
+			Expect(result.Launch.DirectProcesses).To(Equal([]packit.DirectProcess{
+				{Type: "web", Command: command, Default: true},
+				{Type: "release", Command: command},
+			}))
+
+			Expect(buffer.String()).To(ContainSubstring("Running make rel"))
+			Expect(rebar3.ExecuteCall.CallCount).To(Equal(0))
+		})
+
+		context("when git credential bindings are present", func() {
+			var keyPath string
+
+			it.Before(func() {
+				bindingResolver.ResolveCall.Stub = func(typ, _, _ string) ([]servicebindings.Binding, error) {
+					switch typ {
+					case "ssh":
+						return []servicebindings.Binding{
+							{
+								Name: "deploy-key",
+								Entries: map[string]*servicebindings.Entry{
+									"ssh-privatekey": servicebindings.NewWithValue([]byte("very-secret-key")),
+									"known-hosts":    servicebindings.NewWithValue([]byte("github.com ssh-ed25519 AAAA")),
+								},
+							},
+						}, nil
+					case "git-credentials":
+						return []servicebindings.Binding{
+							{
+								Name: "gitlab",
+								Entries: map[string]*servicebindings.Entry{
+									"credentials": servicebindings.NewWithValue([]byte("host=gitlab.example.com\nusername=oauth2\npassword=glpat-secret")),
+								},
+							},
+						}, nil
+					}
+					return nil, nil
+				}
+
+				stub := gnuMake.ExecuteCall.Stub
+				gnuMake.ExecuteCall.Stub = func(execution pexec.Execution) error {
+					for _, variable := range execution.Env {
+						if command, ok := strings.CutPrefix(variable, "GIT_SSH_COMMAND="); ok {
+							keyPath = strings.Trim(strings.Fields(strings.SplitN(command, " -i ", 2)[1])[0], "'")
+							Expect(keyPath).To(BeARegularFile())
+						}
+					}
+					return stub(execution)
+				}
+			})
+
+			it("hands them to make for the build only", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(gnuMake.ExecuteCall.Receives.Execution.Env).To(ContainElements(
+					"GIT_TERMINAL_PROMPT=0",
+					"GIT_CONFIG_KEY_0=credential.helper",
+				))
+				Expect(keyPath).NotTo(BeEmpty())
+				Expect(keyPath).NotTo(BeAnExistingFile())
+
+				for _, layer := range result.Layers {
+					Expect(fmt.Sprint(layer.BuildEnv, layer.LaunchEnv, layer.SharedEnv)).NotTo(ContainSubstring("GIT_"))
+				}
+
+				Expect(buffer.String()).To(ContainSubstring("Configuring git from bindings: gitlab, deploy-key"))
+				Expect(buffer.String()).To(ContainSubstring("Credentials for https://gitlab.example.com"))
+				Expect(buffer.String()).NotTo(ContainSubstring("glpat-secret"))
+				Expect(buffer.String()).NotTo(ContainSubstring("very-secret-key"))
+			})
+		})
+
+		context("when BP_ERLANG_MK_TARGETS is set", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_ERLANG_MK_TARGETS", "deps app rel")).To(Succeed())
+			})
+
+			it("runs those targets", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(gnuMake.ExecuteCall.Receives.Execution.Args).To(Equal([]string{"deps", "app", "rel"}))
+			})
+		})
+
+		context("when the erlang.mk cache was built with the same OTP and dependencies", func() {
+			it.Before(func() {
+				Expect(os.MkdirAll(filepath.Join(layersDir, "erlang-mk-cache", "tmp"), os.ModePerm)).To(Succeed())
+				Expect(os.MkdirAll(filepath.Join(layersDir, "erlang-mk-cache", "deps", "cowboy", "ebin"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(layersDir, "erlang-mk-cache", "deps", "cowboy", "ebin", "dep_built"), nil, 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(layersDir, "erlang-mk-cache", "tmp", "relx"), []byte("relx"), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(layersDir, "erlang-mk-cache", "tmp", "deps.log"), []byte("cowboy"), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(layersDir, "erlang-mk-cache.toml"), []byte(fmt.Sprintf(`
+					[metadata]
+					version = "28.1.1"
+					deps-spec-sha256 = %q
+				`, depsDigest)), 0644)).To(Succeed())
+			})
+
+			it("reuses the build directory and the compiled dependencies", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(filepath.Join(layersDir, "erlang-mk-cache", "tmp", "relx")).To(BeARegularFile())
+				Expect(filepath.Join(layersDir, "erlang-mk-cache", "deps", "cowboy", "ebin", "dep_built")).To(BeARegularFile())
+				Expect(filepath.Join(layersDir, "erlang-mk-cache", "tmp", "deps.log")).NotTo(BeAnExistingFile())
+				Expect(buffer.String()).To(ContainSubstring("Reusing cached layer " + filepath.Join(layersDir, "erlang-mk-cache")))
+			})
+		})
+
+		context("when the erlang.mk cache was built with a different OTP", func() {
+			it.Before(func() {
+				Expect(os.MkdirAll(filepath.Join(layersDir, "erlang-mk-cache", "deps", "cowboy"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(layersDir, "erlang-mk-cache.toml"), []byte(fmt.Sprintf(`
+					[metadata]
+					version = "27.3.4"
+					deps-spec-sha256 = %q
+				`, depsDigest)), 0644)).To(Succeed())
+			})
+
+			it("discards the cache", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(filepath.Join(layersDir, "erlang-mk-cache", "deps", "cowboy")).NotTo(BeAnExistingFile())
+				Expect(result.Layers[2].Metadata).To(HaveKeyWithValue("version", "28.1.1"))
+				Expect(buffer.String()).To(ContainSubstring("Discarding erlang.mk cache: built with OTP 27.3.4"))
+			})
+		})
+
+		context("when the Makefile declares different dependencies", func() {
+			it.Before(func() {
+				Expect(os.MkdirAll(filepath.Join(layersDir, "erlang-mk-cache", "deps", "cowboy"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(layersDir, "erlang-mk-cache.toml"), []byte(`
+					[metadata]
+					version = "28.1.1"
+					deps-spec-sha256 = "some-other-digest"
+				`), 0644)).To(Succeed())
+			})
+
+			it("discards the cache", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(filepath.Join(layersDir, "erlang-mk-cache", "deps", "cowboy")).NotTo(BeAnExistingFile())
+				Expect(buffer.String()).To(ContainSubstring("Discarding erlang.mk cache: the Makefile declares different dependencies"))
+			})
+		})
+
+		context("when make builds no release", func() {
+			it.Before(func() {
+				Expect(os.Remove(filepath.Join(workingDir, "relx.config"))).To(Succeed())
+				gnuMake.ExecuteCall.Stub = nil
+			})
+
+			it("keeps only the cache layer", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Layers).To(HaveLen(3))
+				Expect(result.Layers[2].Name).To(Equal("erlang-mk-cache"))
+				Expect(result.Launch.DirectProcesses).To(BeEmpty())
+			})
+		})
+
+		context("when make fails", func() {
+			it.Before(func() {
+				gnuMake.ExecuteCall.Stub = nil
+				gnuMake.ExecuteCall.Returns.Err = errors.New("exit status 2")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("failed to run make rel: exit status 2"))
+			})
+		})
+	})
+
+	context("when the app is a plain Erlang project", func() {
+		it.Before(func() {
+			Expect(os.MkdirAll(filepath.Join(workingDir, "src"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "src", "tool.erl"), []byte("-module(tool)."), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "src", "tool_lib.erl"), []byte("-module(tool_lib)."), 0644)).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_ERLANG_START_MODULE")).To(Succeed())
+			Expect(os.Unsetenv("BP_ERLANG_START_FUNCTION")).To(Succeed())
+		})
+
+		it("compiles src/*.erl into ebin with erlc", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			execution := erlc.ExecuteCall.Receives.Execution
+			Expect(execution.Args).To(Equal([]string{"-o", "ebin", "-I", "include", "src/tool.erl", "src/tool_lib.erl"}))
+			Expect(execution.Dir).To(Equal(workingDir))
+			Expect(execution.Env).To(ContainElement(HavePrefix("PATH=" + filepath.Join(layersDir, "erlang", "bin") + ":")))
+			Expect(filepath.Join(workingDir, "ebin")).To(BeADirectory())
+			Expect(erl.ExecuteCall.CallCount).To(Equal(0))
+
+			Expect(result.Launch.DirectProcesses).To(BeEmpty())
+			Expect(buffer.String()).To(ContainSubstring("Compiling 2 modules with erlc"))
+			Expect(buffer.String()).To(ContainSubstring("set BP_ERLANG_START_MODULE"))
+		})
+
+		context("when BP_ERLANG_START_MODULE is set", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_ERLANG_START_MODULE", "tool")).To(Succeed())
+			})
+
+			it("registers a process that calls Module:start()", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Launch.DirectProcesses).To(Equal([]packit.DirectProcess{
+					{
+						Type:             "web",
+						Command:          []string{filepath.Join(layersDir, "erlang-launch", "bin", "erl"), "-noshell", "-pa", "ebin", "-s", "tool", "start"},
+						Default:          true,
+						WorkingDirectory: workingDir,
+					},
+				}))
+			})
+
+			context("when BP_ERLANG_START_FUNCTION is set", func() {
+				it.Before(func() {
+					Expect(os.Setenv("BP_ERLANG_START_FUNCTION", "main")).To(Succeed())
+				})
+
+				it("calls that function", func() {
+					result, err := build(buildContext)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(result.Launch.DirectProcesses[0].Command).To(HaveExactElements(HaveSuffix("erl"), "-noshell", "-pa", "ebin", "-s", "tool", "main"))
+				})
+			})
+		})
+
+		context("when the project has an Emakefile", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "Emakefile"), []byte(`{"src/*", [debug_info, {outdir, "ebin"}]}.`), 0644)).To(Succeed())
+				Expect(os.Setenv("BP_ERLANG_START_MODULE", "tool")).To(Succeed())
+			})
+
+			it("runs erl -make", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(erl.ExecuteCall.Receives.Execution.Args).To(Equal([]string{"-make"}))
+				Expect(erl.ExecuteCall.Receives.Execution.Dir).To(Equal(workingDir))
+				Expect(erlc.ExecuteCall.CallCount).To(Equal(0))
+
+				Expect(result.Launch.DirectProcesses[0].Command).To(HaveExactElements(HaveSuffix("erl"), "-noshell", "-pa", "ebin", "-s", "tool", "start"))
+				Expect(buffer.String()).To(ContainSubstring("Compiling with erl -make"))
+			})
+		})
+
+		context("when the plan requires rebar3", func() {
+			it.Before(func() {
+				buildContext.Plan.Entries = []packit.BuildpackPlanEntry{{Name: "rebar3"}}
+				rebar3Installer.ResolveReleaseCall.Returns.Rebar3Release = erlang.Rebar3Release{Version: "3.25.1"}
+			})
+
+			it("leaves compilation to rebar3", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(erlc.ExecuteCall.CallCount).To(Equal(0))
+			})
+		})
+
+		context("when compilation fails", func() {
+			it.Before(func() {
+				erlc.ExecuteCall.Returns.Err = errors.New("exit status 1")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("failed to compile with erlc: exit status 1"))
+			})
+		})
+	})
+
+	context("when the app has a rebar.lock", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "rebar.lock"), []byte(`{"1.2.0",
+[{<<"cowboy">>,{pkg,<<"cowboy">>,<<"2.10.0">>},0}]}.
+[{pkg_hash_ext,[{<<"cowboy">>, <<"3AFDCCB7183CC6F143CB14D3CF51FA00E53DB9EC80CDCD525482F5E99BC41D6B">>}]}].
+`), 0644)).To(Succeed())
+		})
+
+		it("adds the locked dependencies to the launch SBOM", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Launch.SBOM).NotTo(BeNil())
+
+			var syft packit.SBOMFormat
+			for _, format := range result.Launch.SBOM.Formats() {
+				if format.Extension == "syft.json" {
+					syft = format
+				}
+			}
+
+			content, err := io.ReadAll(syft.Content)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(ContainSubstring(`"purl":"pkg:hex/cowboy@2.10.0"`))
+			Expect(string(content)).To(ContainSubstring(`"pkgHashExt":"3afdccb7183cc6f143cb14d3cf51fa00e53db9ec80cdcd525482f5e99bc41d6b"`))
+
+			Expect(buffer.String()).To(ContainSubstring("Recording 1 dependencies from rebar.lock in the SBOM"))
+		})
+
+		context("when the rebar.lock is malformed", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "rebar.lock"), []byte(`{`), 0644)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(ContainSubstring("failed to parse")))
+			})
+		})
+	})
+
+	context("when the app has a mix.lock", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "mix.lock"), []byte(`%{
+  "jason": {:hex, :jason, "1.4.1", "af1504e35f629ddcdd6addb3513c3853991f694921b1b9368b0bd32beb9f1b63", [:mix], [], "hexpm", "fbb01ecdfd565b56261302f7e1fcc27c4fb8f32d56eab74db621fc154604a7a1"},
+}
+`), 0644)).To(Succeed())
+		})
+
+		it("adds the locked dependencies to the build SBOM", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Launch.SBOM).To(BeNil())
+			Expect(result.Build.SBOM).NotTo(BeNil())
+
+			var syft packit.SBOMFormat
+			for _, format := range result.Build.SBOM.Formats() {
+				if format.Extension == "syft.json" {
+					syft = format
+				}
+			}
+
+			content, err := io.ReadAll(syft.Content)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(ContainSubstring(`"purl":"pkg:hex/jason@1.4.1"`))
+			Expect(string(content)).To(ContainSubstring(`"language":"elixir"`))
+			Expect(string(content)).To(ContainSubstring(`"pkgHashExt":"fbb01ecdfd565b56261302f7e1fcc27c4fb8f32d56eab74db621fc154604a7a1"`))
+
+			Expect(buffer.String()).To(ContainSubstring("Recording 1 dependencies from mix.lock in the build SBOM"))
+		})
+	})
+
+	context("when BP_ERLANG_EXCLUDE_APPS is set", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_ERLANG_EXCLUDE_APPS", "wx")).To(Succeed())
+
+			installer.InstallCall.Stub = func(_, layerPath string) (string, error) {
+				for name, props := range map[string]string{
+					"kernel": "",
+					"stdlib": "{applications, [kernel]}",
+					"wx":     "{applications, [kernel, stdlib]}",
+				} {
+					ebin := filepath.Join(layerPath, "lib", name+"-1.0", "ebin")
+					Expect(os.MkdirAll(ebin, os.ModePerm)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(ebin, name+".app"), []byte(fmt.Sprintf("{application, %s, [%s]}.", name, props)), 0644)).To(Succeed())
+				}
+				return "", nil
+			}
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_ERLANG_EXCLUDE_APPS")).To(Succeed())
+		})
+
+		it("prunes the applications from the launch layer only", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(filepath.Join(layersDir, "erlang-launch", "lib", "wx-1.0")).NotTo(BeAnExistingFile())
+			Expect(filepath.Join(layersDir, "erlang-launch", "lib", "stdlib-1.0")).To(BeADirectory())
+			Expect(filepath.Join(layersDir, "erlang", "lib", "wx-1.0")).To(BeADirectory())
+
+			Expect(result.Layers[1].Metadata).To(HaveKeyWithValue("exclude-apps", "wx"))
+			Expect(buffer.String()).To(ContainSubstring("Pruned OTP applications: wx"))
+		})
+
+		context("when the exclusion would break a kept application", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_ERLANG_EXCLUDE_APPS", "kernel")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(ContainSubstring("failed to prune OTP applications")))
+			})
+		})
+	})
+
+	context("when BP_ERLANG_STRIP_BEAMS is true", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_ERLANG_STRIP_BEAMS", "true")).To(Succeed())
+
+			installer.InstallCall.Stub = func(_, layerPath string) (string, error) {
+				ebin := filepath.Join(layerPath, "lib", "stdlib-7.1", "ebin")
+				Expect(os.MkdirAll(ebin, os.ModePerm)).To(Succeed())
+				return "", os.WriteFile(filepath.Join(ebin, "lists.beam"), buildBeam(
+					"AtU8", "atoms", "Code", "code", "ImpT", "imp", "ExpT", "exp", "Docs", "docs",
+				), 0644)
+			}
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_ERLANG_STRIP_BEAMS")).To(Succeed())
+		})
+
+		it("strips modules in the launch layer only", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			launchBeam, err := os.ReadFile(filepath.Join(layersDir, "erlang-launch", "lib", "stdlib-7.1", "ebin", "lists.beam"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(launchBeam).NotTo(ContainSubstring("Docs"))
+
+			buildBeamContent, err := os.ReadFile(filepath.Join(layersDir, "erlang", "lib", "stdlib-7.1", "ebin", "lists.beam"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(buildBeamContent).To(ContainSubstring("Docs"))
+
+			Expect(result.Layers[1].Metadata).To(HaveKeyWithValue("strip-beams", true))
+			Expect(buffer.String()).To(ContainSubstring("Stripped 1 .beam files, saved 12 B"))
+		})
+
+		context("when a module cannot be stripped", func() {
+			it.Before(func() {
+				installer.InstallCall.Stub = func(_, layerPath string) (string, error) {
+					ebin := filepath.Join(layerPath, "lib", "stdlib-7.1", "ebin")
+					Expect(os.MkdirAll(ebin, os.ModePerm)).To(Succeed())
+					return "", os.WriteFile(filepath.Join(ebin, "lists.beam"), buildBeam("AtU8", "atoms", "Dbgi", "debug"), 0644)
+				}
+			})
+
+			it("fails the build", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(ContainSubstring("failed to strip .beam files")))
+				Expect(err).To(MatchError(ContainSubstring("missing the Code chunk")))
+			})
+		})
 	})
 
 	context("when the layer is already cached", func() {
 		it.Before(func() {
-			err := os.MkdirAll(filepath.Join(layersDir, "erlang"), 0755)
+			err := os.MkdirAll(filepath.Join(layersDir, "erlang", "bin"), 0755)
 			Expect(err).NotTo(HaveOccurred())
 
-			err = os.WriteFile(filepath.Join(layersDir, "erlang.toml"), []byte(`
+			err = os.WriteFile(filepath.Join(layersDir, "erlang", "bin", "erl"), []byte("erl"), 0755)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.WriteFile(filepath.Join(layersDir, "erlang", "provenance.intoto.json"), []byte(`{"cached": true}`), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			manifest, err := erlang.ComputeLayerManifest(filepath.Join(layersDir, "erlang"))
+			Expect(err).NotTo(HaveOccurred())
+
+			// packit writes the layer environment after the manifest is
+			// recorded
+			err = os.MkdirAll(filepath.Join(layersDir, "erlang", "env.build"), 0755)
+			Expect(err).NotTo(HaveOccurred())
+
+			for name, value := range map[string]string{
+				"ERLANG_HOME.default": filepath.Join(layersDir, "erlang"),
+				"PATH.prepend":        filepath.Join(layersDir, "erlang", "bin"),
+				"PATH.delim":          ":",
+			} {
+				err = os.WriteFile(filepath.Join(layersDir, "erlang", "env.build", name), []byte(value), 0644)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			err = os.WriteFile(filepath.Join(layersDir, "erlang.toml"), []byte(fmt.Sprintf(`
 				[metadata]
 				version = "28.1.1"
 				arch = "amd64"
 				ubuntu-version = "ubuntu-22.04"
-			`), 0644)
+				source-date-epoch = "315532801"
+				layout-version = %q
+				file-count = %d
+				checksum = %q
+			`, erlang.LayoutVersion, manifest.FileCount, manifest.Checksum)), 0644)
 			Expect(err).NotTo(HaveOccurred())
 		})
 
@@ -123,15 +1808,161 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 			result, err := build(buildContext)
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(result.Layers).To(HaveLen(1))
+			Expect(result.Layers).To(HaveLen(2))
 			layer := result.Layers[0]
 
 			Expect(layer.Build).To(BeTrue())
-			Expect(layer.Launch).To(BeTrue())
+			Expect(layer.Launch).To(BeFalse())
 			Expect(layer.Cache).To(BeTrue())
 
+			Expect(result.Layers[1].Launch).To(BeTrue())
+
 			Expect(buffer.String()).To(ContainSubstring("Reusing cached layer"))
 			Expect(buffer.String()).NotTo(ContainSubstring("Downloading Erlang"))
+			Expect(buffer.String()).To(ContainSubstring("Assembling launch layer"))
+
+			content, err := os.ReadFile(filepath.Join(layersDir, "erlang-launch", "provenance.intoto.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal(`{"cached": true}`))
+		})
+
+		context("when the launch layer metadata matches", func() {
+			it.Before(func() {
+				err := os.WriteFile(filepath.Join(layersDir, "erlang-launch.toml"), []byte(fmt.Sprintf(`
+					[metadata]
+					version = "28.1.1"
+					arch = "amd64"
+					ubuntu-version = "ubuntu-22.04"
+					source-date-epoch = "315532801"
+					layout-version = %q
+				`, erlang.LayoutVersion)), 0644)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			it("reuses the launch layer", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Layers).To(HaveLen(2))
+				Expect(result.Layers[1].Launch).To(BeTrue())
+
+				Expect(buffer.String()).To(ContainSubstring("Reusing launch layer"))
+				Expect(buffer.String()).NotTo(ContainSubstring("Assembling launch layer"))
+			})
+		})
+
+		context("when SOURCE_DATE_EPOCH has changed", func() {
+			it.Before(func() {
+				Expect(os.Setenv("SOURCE_DATE_EPOCH", "1700000000")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("SOURCE_DATE_EPOCH")).To(Succeed())
+			})
+
+			it("reinstalls the layer", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(installer.InstallCall.CallCount).To(Equal(1))
+				Expect(result.Layers[0].Metadata).To(HaveKeyWithValue("source-date-epoch", "1700000000"))
+			})
+		})
+
+		context("when the cached layer is missing files", func() {
+			it.Before(func() {
+				Expect(os.Remove(filepath.Join(layersDir, "erlang", "bin", "erl"))).To(Succeed())
+			})
+
+			it("rejects the cache and reinstalls", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(installer.InstallCall.CallCount).To(Equal(1))
+
+				Expect(buffer.String()).To(ContainSubstring("Rejecting cached layer"))
+				Expect(buffer.String()).To(ContainSubstring("expected 2 files, found 1"))
+				Expect(buffer.String()).To(ContainSubstring("Downloading Erlang 28.1.1"))
+			})
+		})
+
+		context("when a key binary has been modified", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(layersDir, "erlang", "bin", "erl"), []byte("ERL"), 0755)).To(Succeed())
+			})
+
+			it("rejects the cache and reinstalls", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(installer.InstallCall.CallCount).To(Equal(1))
+				Expect(buffer.String()).To(ContainSubstring("checksum of key binaries does not match"))
+			})
+		})
+
+		context("when the cached layer was built with a different layout", func() {
+			it.Before(func() {
+				err := os.WriteFile(filepath.Join(layersDir, "erlang.toml"), []byte(`
+					[metadata]
+					version = "28.1.1"
+					arch = "amd64"
+					ubuntu-version = "ubuntu-22.04"
+					source-date-epoch = "315532801"
+					layout-version = "0"
+				`), 0644)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			it("rejects the cache and reinstalls", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(installer.InstallCall.CallCount).To(Equal(1))
+				Expect(buffer.String()).To(ContainSubstring(`layer layout version "0" does not match buildpack layout version`))
+			})
+		})
+
+		context("when the cached layer has no layout version", func() {
+			it.Before(func() {
+				err := os.WriteFile(filepath.Join(layersDir, "erlang.toml"), []byte(`
+					[metadata]
+					version = "28.1.1"
+					arch = "amd64"
+					ubuntu-version = "ubuntu-22.04"
+					source-date-epoch = "315532801"
+				`), 0644)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			it("rejects the cache and reinstalls", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(installer.InstallCall.CallCount).To(Equal(1))
+				Expect(buffer.String()).To(ContainSubstring(`layer layout version "" does not match`))
+			})
+		})
+
+		context("when the cached layer has no manifest", func() {
+			it.Before(func() {
+				err := os.WriteFile(filepath.Join(layersDir, "erlang.toml"), []byte(fmt.Sprintf(`
+					[metadata]
+					version = "28.1.1"
+					arch = "amd64"
+					ubuntu-version = "ubuntu-22.04"
+					source-date-epoch = "315532801"
+					layout-version = %q
+				`, erlang.LayoutVersion)), 0644)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			it("rejects the cache and reinstalls", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(installer.InstallCall.CallCount).To(Equal(1))
+				Expect(buffer.String()).To(ContainSubstring("no manifest recorded in layer metadata"))
+			})
 		})
 	})
 
@@ -153,7 +1984,7 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 			result, err := build(buildContext)
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(result.Layers).To(HaveLen(1))
+			Expect(result.Layers).To(HaveLen(2))
 			layer := result.Layers[0]
 
 			Expect(layer.Metadata).To(HaveKeyWithValue("version", "28.1.1"))
@@ -232,3 +2063,9 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		})
 	})
 }
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
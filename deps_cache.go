@@ -0,0 +1,104 @@
+package erlang
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/paketo-buildpacks/packit/v2/fs"
+)
+
+const (
+	Rebar3DepsLayerName = "rebar3-deps"
+
+	ProfileKey = "profile"
+)
+
+// RestoreDependencies copies the compiled dependencies in cacheDir into
+// libDir, the _build/<profile>/lib directory of the app, and returns their
+// names. Dependencies already present in libDir are left alone.
+func RestoreDependencies(cacheDir, libDir string) ([]string, error) {
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list cached dependencies: %w", err)
+	}
+
+	var restored []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		destination := filepath.Join(libDir, entry.Name())
+		_, err = os.Lstat(destination)
+		if err == nil {
+			continue
+		}
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to stat %s: %w", destination, err)
+		}
+
+		err = os.MkdirAll(libDir, os.ModePerm)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", libDir, err)
+		}
+
+		err = fs.Copy(filepath.Join(cacheDir, entry.Name()), destination)
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore dependency %s: %w", entry.Name(), err)
+		}
+
+		restored = append(restored, entry.Name())
+	}
+
+	return restored, nil
+}
+
+// SaveDependencies copies the named dependencies from libDir into cacheDir and
+// returns the names it saved. Anything else in libDir, such as the app's own
+// applications or symlinked checkouts, is not cached, so app code is always
+// compiled afresh.
+func SaveDependencies(libDir, cacheDir string, names []string) ([]string, error) {
+	err := os.MkdirAll(cacheDir, os.ModePerm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", cacheDir, err)
+	}
+
+	var saved []string
+	for _, name := range names {
+		source := filepath.Join(libDir, name)
+		info, err := os.Lstat(source)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to stat %s: %w", source, err)
+		}
+		if !info.IsDir() {
+			continue
+		}
+
+		err = fs.Copy(source, filepath.Join(cacheDir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to cache dependency %s: %w", name, err)
+		}
+
+		saved = append(saved, name)
+	}
+
+	sort.Strings(saved)
+	return saved, nil
+}
+
+// lockedNames returns the names of the dependencies in a rebar.lock.
+func lockedNames(deps []LockedDependency) []string {
+	var names []string
+	for _, dep := range deps {
+		names = append(names, dep.Name)
+	}
+	return names
+}
@@ -0,0 +1,81 @@
+package erlang_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SnakeDoc/erlang-cnb"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testDepsCache(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		cacheDir string
+		libDir   string
+	)
+
+	it.Before(func() {
+		dir := t.TempDir()
+		cacheDir = filepath.Join(dir, "cache", "lib")
+		libDir = filepath.Join(dir, "_build", "prod", "lib")
+	})
+
+	context("SaveDependencies", func() {
+		it.Before(func() {
+			for _, app := range []string{"cowboy", "ranch", "myapp"} {
+				Expect(os.MkdirAll(filepath.Join(libDir, app, "ebin"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(libDir, app, "ebin", app+".beam"), []byte(app), 0644)).To(Succeed())
+			}
+			Expect(os.Symlink(filepath.Join(libDir, "myapp"), filepath.Join(libDir, "checkout"))).To(Succeed())
+		})
+
+		it("copies only the named dependencies", func() {
+			saved, err := erlang.SaveDependencies(libDir, cacheDir, []string{"ranch", "cowboy", "checkout", "missing"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(saved).To(Equal([]string{"cowboy", "ranch"}))
+
+			Expect(filepath.Join(cacheDir, "cowboy", "ebin", "cowboy.beam")).To(BeARegularFile())
+			Expect(filepath.Join(cacheDir, "ranch", "ebin", "ranch.beam")).To(BeARegularFile())
+			Expect(filepath.Join(cacheDir, "myapp")).NotTo(BeAnExistingFile())
+			Expect(filepath.Join(cacheDir, "checkout")).NotTo(BeAnExistingFile())
+		})
+	})
+
+	context("RestoreDependencies", func() {
+		it.Before(func() {
+			Expect(os.MkdirAll(filepath.Join(cacheDir, "cowboy", "ebin"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(cacheDir, "cowboy", "ebin", "cowboy.beam"), []byte("cached"), 0644)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(cacheDir, "ranch", "ebin"), os.ModePerm)).To(Succeed())
+		})
+
+		it("copies the cached dependencies into the lib directory", func() {
+			restored, err := erlang.RestoreDependencies(cacheDir, libDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(restored).To(Equal([]string{"cowboy", "ranch"}))
+
+			content, err := os.ReadFile(filepath.Join(libDir, "cowboy", "ebin", "cowboy.beam"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal("cached"))
+		})
+
+		it("leaves dependencies that are already present", func() {
+			Expect(os.MkdirAll(filepath.Join(libDir, "cowboy"), os.ModePerm)).To(Succeed())
+
+			restored, err := erlang.RestoreDependencies(cacheDir, libDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(restored).To(Equal([]string{"ranch"}))
+			Expect(filepath.Join(libDir, "cowboy", "ebin")).NotTo(BeAnExistingFile())
+		})
+
+		it("restores nothing from an empty cache", func() {
+			restored, err := erlang.RestoreDependencies(filepath.Join(cacheDir, "missing"), libDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(restored).To(BeEmpty())
+		})
+	})
+}
@@ -1,6 +1,7 @@
 package erlang
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 
@@ -10,6 +11,7 @@ import (
 //go:generate faux --interface VersionParser --output fakes/version_parser.go
 type VersionParser interface {
 	ParseVersion(path string) (version string, err error)
+	ParseRebar3Version(path string) (version string, err error)
 }
 
 type BuildPlanMetadata struct {
@@ -47,13 +49,117 @@ func Detect(toolVersionsParser VersionParser) packit.DetectFunc {
 			})
 		}
 
-		return packit.DetectResult{
-			Plan: packit.BuildPlan{
-				Provides: []packit.BuildPlanProvision{
-					{Name: "erlang"},
-				},
-				Requires: requirements,
+		erlangMk, err := UsesErlangMk(context.WorkingDir)
+		if err != nil {
+			return packit.DetectResult{}, err
+		}
+
+		rebar3, err := rebar3Requirement(context.WorkingDir, toolVersionsParser, erlangMk)
+		if err != nil {
+			return packit.DetectResult{}, err
+		}
+
+		// an app that builds with rebar3, erlang.mk or as a plain project needs
+		// erlang, even when it pins no version; every provision of the plan
+		// must be required
+		if len(requirements) == 0 {
+			builds := rebar3 != nil || erlangMk
+			if !builds {
+				_, builds, err = FindPlainProject(context.WorkingDir)
+				if err != nil {
+					return packit.DetectResult{}, err
+				}
+			}
+
+			if builds {
+				requirements = append(requirements, packit.BuildPlanRequirement{Name: "erlang"})
+			}
+		}
+
+		plan := packit.BuildPlan{
+			Provides: []packit.BuildPlanProvision{
+				{Name: "erlang"},
+			},
+			Requires: requirements,
+		}
+
+		withRebar3 := packit.BuildPlan{
+			Provides: []packit.BuildPlanProvision{
+				{Name: "erlang"},
+				{Name: Rebar3},
 			},
-		}, nil
+			Requires: requirements,
+		}
+
+		// erlang.mk projects build with make, and get rebar3 only when they
+		// pin it
+		if erlangMk {
+			build := packit.BuildPlan{
+				Provides: []packit.BuildPlanProvision{{Name: "erlang"}},
+				Requires: append([]packit.BuildPlanRequirement{}, requirements...),
+			}
+			if rebar3 != nil {
+				build.Provides = append(build.Provides, packit.BuildPlanProvision{Name: Rebar3})
+				build.Requires = append(build.Requires, *rebar3)
+			}
+			build.Provides = append(build.Provides, packit.BuildPlanProvision{Name: ErlangMk})
+			build.Requires = append(build.Requires, packit.BuildPlanRequirement{Name: ErlangMk})
+
+			return packit.DetectResult{Plan: build}, nil
+		}
+
+		// rebar3 is installed when the app uses it; otherwise it is only
+		// offered to later buildpacks that require it
+		if rebar3 != nil {
+			withRebar3.Requires = append(append([]packit.BuildPlanRequirement{}, requirements...), *rebar3)
+			return packit.DetectResult{Plan: withRebar3}, nil
+		}
+
+		plan.Or = []packit.BuildPlan{withRebar3}
+
+		return packit.DetectResult{Plan: plan}, nil
+	}
+}
+
+// rebar3Requirement returns the rebar3 requirement of an app that builds with
+// rebar3: one that has a rebar.config, pins rebar in .tool-versions or sets
+// BP_REBAR3_VERSION. It returns nil for other apps. The rebar.config of an
+// erlang.mk project only describes it to rebar3 users, so it is ignored.
+func rebar3Requirement(workingDir string, toolVersionsParser VersionParser, erlangMk bool) (*packit.BuildPlanRequirement, error) {
+	requirement := &packit.BuildPlanRequirement{Name: Rebar3}
+
+	if version := os.Getenv("BP_REBAR3_VERSION"); version != "" {
+		requirement.Metadata = BuildPlanMetadata{
+			Version:       version,
+			VersionSource: "BP_REBAR3_VERSION",
+		}
+		return requirement, nil
+	}
+
+	version, err := toolVersionsParser.ParseRebar3Version(filepath.Join(workingDir, ".tool-versions"))
+	if err != nil {
+		return nil, err
 	}
+
+	if version != "" {
+		requirement.Metadata = BuildPlanMetadata{
+			Version:       version,
+			VersionSource: ".tool-versions",
+		}
+		return requirement, nil
+	}
+
+	if erlangMk {
+		return nil, nil
+	}
+
+	_, err = os.Stat(filepath.Join(workingDir, "rebar.config"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to stat rebar.config: %w", err)
+	}
+
+	return requirement, nil
 }
@@ -35,6 +35,7 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 	it.After(func() {
 		Expect(os.RemoveAll(workingDir)).To(Succeed())
 		Expect(os.Unsetenv("BP_ERLANG_VERSION")).To(Succeed())
+		Expect(os.Unsetenv("BP_REBAR3_VERSION")).To(Succeed())
 	})
 
 	it("always provides erlang", func() {
@@ -46,6 +47,14 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 			Provides: []packit.BuildPlanProvision{
 				{Name: "erlang"},
 			},
+			Or: []packit.BuildPlan{
+				{
+					Provides: []packit.BuildPlanProvision{
+						{Name: "erlang"},
+						{Name: "rebar3"},
+					},
+				},
+			},
 		}))
 	})
 
@@ -72,6 +81,23 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 						},
 					},
 				},
+				Or: []packit.BuildPlan{
+					{
+						Provides: []packit.BuildPlanProvision{
+							{Name: "erlang"},
+							{Name: "rebar3"},
+						},
+						Requires: []packit.BuildPlanRequirement{
+							{
+								Name: "erlang",
+								Metadata: erlang.BuildPlanMetadata{
+									Version:       "27.3.4",
+									VersionSource: "BP_ERLANG_VERSION",
+								},
+							},
+						},
+					},
+				},
 			}))
 		})
 	})
@@ -99,6 +125,23 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 						},
 					},
 				},
+				Or: []packit.BuildPlan{
+					{
+						Provides: []packit.BuildPlanProvision{
+							{Name: "erlang"},
+							{Name: "rebar3"},
+						},
+						Requires: []packit.BuildPlanRequirement{
+							{
+								Name: "erlang",
+								Metadata: erlang.BuildPlanMetadata{
+									Version:       "28.1.1",
+									VersionSource: ".tool-versions",
+								},
+							},
+						},
+					},
+				},
 			}))
 
 			Expect(toolVersionsParser.ParseVersionCall.Receives.Path).To(Equal(filepath.Join(workingDir, ".tool-versions")))
@@ -136,10 +179,250 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 						},
 					},
 				},
+				Or: []packit.BuildPlan{
+					{
+						Provides: []packit.BuildPlanProvision{
+							{Name: "erlang"},
+							{Name: "rebar3"},
+						},
+						Requires: []packit.BuildPlanRequirement{
+							{
+								Name: "erlang",
+								Metadata: erlang.BuildPlanMetadata{
+									Version:       "27.3.4",
+									VersionSource: "BP_ERLANG_VERSION",
+								},
+							},
+							{
+								Name: "erlang",
+								Metadata: erlang.BuildPlanMetadata{
+									Version:       "28.1.1",
+									VersionSource: ".tool-versions",
+								},
+							},
+						},
+					},
+				},
+			}))
+		})
+	})
+
+	context("when the app has a rebar.config", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "rebar.config"), []byte("{deps, []}."), 0644)).To(Succeed())
+		})
+
+		it("provides and requires erlang and rebar3", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan).To(Equal(packit.BuildPlan{
+				Provides: []packit.BuildPlanProvision{
+					{Name: "erlang"},
+					{Name: "rebar3"},
+				},
+				Requires: []packit.BuildPlanRequirement{
+					{Name: "erlang"},
+					{Name: "rebar3"},
+				},
+			}))
+		})
+
+		context("when the app pins an erlang version", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_ERLANG_VERSION", "28.1")).To(Succeed())
+			})
+
+			it("requires erlang only with that version", func() {
+				result, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Plan.Requires).To(Equal([]packit.BuildPlanRequirement{
+					{
+						Name: "erlang",
+						Metadata: erlang.BuildPlanMetadata{
+							Version:       "28.1",
+							VersionSource: "BP_ERLANG_VERSION",
+						},
+					},
+					{Name: "rebar3"},
+				}))
+			})
+		})
+	})
+
+	context("when .tool-versions pins rebar", func() {
+		it.Before(func() {
+			toolVersionsParser.ParseRebar3VersionCall.Returns.Version = "3.24.0"
+		})
+
+		it("requires rebar3 with version from .tool-versions", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan.Provides).To(ContainElement(packit.BuildPlanProvision{Name: "rebar3"}))
+			Expect(result.Plan.Requires).To(Equal([]packit.BuildPlanRequirement{
+				{Name: "erlang"},
+				{
+					Name: "rebar3",
+					Metadata: erlang.BuildPlanMetadata{
+						Version:       "3.24.0",
+						VersionSource: ".tool-versions",
+					},
+				},
+			}))
+
+			Expect(toolVersionsParser.ParseRebar3VersionCall.Receives.Path).To(Equal(filepath.Join(workingDir, ".tool-versions")))
+		})
+	})
+
+	context("when BP_REBAR3_VERSION is set", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_REBAR3_VERSION", "3.25.1")).To(Succeed())
+			toolVersionsParser.ParseRebar3VersionCall.Returns.Version = "3.24.0"
+		})
+
+		it("takes precedence over .tool-versions", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan.Requires).To(Equal([]packit.BuildPlanRequirement{
+				{Name: "erlang"},
+				{
+					Name: "rebar3",
+					Metadata: erlang.BuildPlanMetadata{
+						Version:       "3.25.1",
+						VersionSource: "BP_REBAR3_VERSION",
+					},
+				},
 			}))
 		})
 	})
 
+	context("when the Makefile includes erlang.mk", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "Makefile"), []byte(`PROJECT = hello
+
+DEPS = cowboy
+
+include $(if $(ERLANG_MK_FILENAME),$(ERLANG_MK_FILENAME),erlang.mk)
+`), 0644)).To(Succeed())
+		})
+
+		it("provides and requires erlang and erlang.mk", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan).To(Equal(packit.BuildPlan{
+				Provides: []packit.BuildPlanProvision{
+					{Name: "erlang"},
+					{Name: "erlang.mk"},
+				},
+				Requires: []packit.BuildPlanRequirement{
+					{Name: "erlang"},
+					{Name: "erlang.mk"},
+				},
+			}))
+		})
+
+		context("when the project also has a rebar.config", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "rebar.config"), []byte("{deps, []}."), 0644)).To(Succeed())
+			})
+
+			it("does not require rebar3", func() {
+				result, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Plan.Requires).To(Equal([]packit.BuildPlanRequirement{
+					{Name: "erlang"},
+					{Name: "erlang.mk"},
+				}))
+			})
+		})
+
+		context("when BP_REBAR3_VERSION is set", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_REBAR3_VERSION", "3.25.1")).To(Succeed())
+			})
+
+			it("requires both rebar3 and erlang.mk", func() {
+				result, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Plan.Provides).To(Equal([]packit.BuildPlanProvision{
+					{Name: "erlang"},
+					{Name: "rebar3"},
+					{Name: "erlang.mk"},
+				}))
+				Expect(result.Plan.Requires).To(Equal([]packit.BuildPlanRequirement{
+					{Name: "erlang"},
+					{
+						Name: "rebar3",
+						Metadata: erlang.BuildPlanMetadata{
+							Version:       "3.25.1",
+							VersionSource: "BP_REBAR3_VERSION",
+						},
+					},
+					{Name: "erlang.mk"},
+				}))
+			})
+		})
+	})
+
+	context("when the Makefile does not include erlang.mk", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "Makefile"), []byte("all:\n\terlc src/*.erl\n"), 0644)).To(Succeed())
+		})
+
+		it("does not require erlang.mk", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan.Requires).To(BeEmpty())
+			Expect(result.Plan.Provides).To(Equal([]packit.BuildPlanProvision{{Name: "erlang"}}))
+		})
+	})
+
+	context("when the app is a plain Erlang project", func() {
+		it.Before(func() {
+			Expect(os.MkdirAll(filepath.Join(workingDir, "src"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "src", "tool.erl"), []byte("-module(tool)."), 0644)).To(Succeed())
+		})
+
+		it("requires erlang", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan.Requires).To(Equal([]packit.BuildPlanRequirement{
+				{Name: "erlang"},
+			}))
+		})
+
+		context("when it is a Mix project", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "mix.exs"), []byte("defmodule Tool.MixProject do end"), 0644)).To(Succeed())
+			})
+
+			it("does not require erlang", func() {
+				result, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Plan.Requires).To(BeEmpty())
+			})
+		})
+	})
+
 	context("failure cases", func() {
 		context("when parsing .tool-versions fails", func() {
 			it.Before(func() {
@@ -153,5 +436,18 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 				Expect(err).To(MatchError(os.ErrPermission))
 			})
 		})
+
+		context("when parsing the rebar version from .tool-versions fails", func() {
+			it.Before(func() {
+				toolVersionsParser.ParseRebar3VersionCall.Returns.Err = os.ErrPermission
+			})
+
+			it("returns an error", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).To(MatchError(os.ErrPermission))
+			})
+		})
 	})
 }
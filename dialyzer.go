@@ -0,0 +1,69 @@
+package erlang
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	DialyzerLayerName = "dialyzer-plt"
+
+	PLTAppsKey = "plt-apps"
+
+	// dialyzerWarningsMarker is printed by rebar3 when dialyzer finished but
+	// reported warnings, which rebar3 turns into a failed exit status.
+	dialyzerWarningsMarker = "Warnings occurred running dialyzer"
+)
+
+// DefaultBasePLTApps are the applications rebar3 puts in the base PLT unless
+// rebar.config sets base_plt_apps.
+var DefaultBasePLTApps = []string{"erts", "crypto", "kernel", "stdlib"}
+
+// DialyzerPLTApps returns the sorted applications of the PLTs that rebar3
+// dialyzer builds for the rebar.config at path: the base_plt_apps, or their
+// default, and the plt_extra_apps of the dialyzer section.
+func DialyzerPLTApps(path string) ([]string, error) {
+	apps := append([]string{}, DefaultBasePLTApps...)
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			sort.Strings(apps)
+			return apps, nil
+		}
+		return nil, err
+	}
+
+	terms, err := ParseTerms(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if dialyzer, ok := proplistValue(List(terms), "dialyzer"); ok {
+		if base, ok := proplistValue(asList(dialyzer), "base_plt_apps"); ok {
+			apps = atomList(base)
+		}
+		if extra, ok := proplistValue(asList(dialyzer), "plt_extra_apps"); ok {
+			apps = append(apps, atomList(extra)...)
+		}
+	}
+
+	sort.Strings(apps)
+
+	var unique []string
+	for i, app := range apps {
+		if i == 0 || app != apps[i-1] {
+			unique = append(unique, app)
+		}
+	}
+
+	return unique, nil
+}
+
+// dialyzerReportedWarnings reports whether a failed rebar3 dialyzer run only
+// found warnings, as opposed to failing to analyse the code at all.
+func dialyzerReportedWarnings(output string) bool {
+	return strings.Contains(output, dialyzerWarningsMarker)
+}
@@ -0,0 +1,61 @@
+package erlang_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SnakeDoc/erlang-cnb"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testDialyzer(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		path string
+	)
+
+	it.Before(func() {
+		path = filepath.Join(t.TempDir(), "rebar.config")
+	})
+
+	context("DialyzerPLTApps", func() {
+		it("defaults to the rebar3 base PLT applications", func() {
+			Expect(os.WriteFile(path, []byte("{deps, []}."), 0644)).To(Succeed())
+
+			apps, err := erlang.DialyzerPLTApps(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(apps).To(Equal([]string{"crypto", "erts", "kernel", "stdlib"}))
+		})
+
+		it("reads base_plt_apps and plt_extra_apps", func() {
+			Expect(os.WriteFile(path, []byte(`
+{dialyzer, [{warnings, [unknown]},
+            {base_plt_apps, [stdlib, kernel, erts]},
+            {plt_extra_apps, [ssl, stdlib, public_key]}]}.
+`), 0644)).To(Succeed())
+
+			apps, err := erlang.DialyzerPLTApps(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(apps).To(Equal([]string{"erts", "kernel", "public_key", "ssl", "stdlib"}))
+		})
+
+		it("uses the defaults without a rebar.config", func() {
+			apps, err := erlang.DialyzerPLTApps(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(apps).To(Equal([]string{"crypto", "erts", "kernel", "stdlib"}))
+		})
+
+		context("when rebar.config is malformed", func() {
+			it("returns an error", func() {
+				Expect(os.WriteFile(path, []byte("{dialyzer, ["), 0644)).To(Succeed())
+
+				_, err := erlang.DialyzerPLTApps(path)
+				Expect(err).To(MatchError(ContainSubstring("failed to parse " + path)))
+			})
+		})
+	})
+}
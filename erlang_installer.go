@@ -1,10 +1,21 @@
 package erlang
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
+	"syscall"
 
+	"github.com/paketo-buildpacks/packit/v2/pexec"
 	"github.com/paketo-buildpacks/packit/v2/vacation"
 )
 
@@ -30,21 +41,225 @@ func (i ErlangInstaller) BuildDownloadURL(arch, ubuntuVersion, version string) s
 	return fmt.Sprintf(DownloadURLTemplate, arch, ubuntuVersion, normalizedVersion)
 }
 
-func (i ErlangInstaller) Install(url, layerPath string) error {
+// Install downloads the archive at url and extracts it into a staging
+// directory next to layerPath. The staged tree is only moved into layerPath
+// once it has passed the integrity and smoke checks, so a failed install never
+// leaves a partially populated layer behind. The SHA-256 checksum of the
+// downloaded archive is returned.
+func (i ErlangInstaller) Install(url, layerPath string) (string, error) {
+	parentDir := filepath.Dir(layerPath)
+
+	archive, err := os.CreateTemp(parentDir, ".erlang-download-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create download file in %s: %w", parentDir, err)
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+
 	resp, err := http.Get(url)
 	if err != nil {
-		return fmt.Errorf("failed to download Erlang from %s: %w", url, err)
+		return "", fmt.Errorf("failed to download Erlang from %s: %w", url, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to download Erlang from %s: received status code %d", url, resp.StatusCode)
+		return "", fmt.Errorf("failed to download Erlang from %s: received status code %d", url, resp.StatusCode)
+	}
+
+	// fail before the download fills the disk; the extracted size is only
+	// known, and checked, once the archive is here
+	if resp.ContentLength > 0 {
+		err = checkFreeSpace(parentDir, resp.ContentLength)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(archive, hash), resp.Body)
+	if err != nil {
+		return "", diskSpaceError(fmt.Errorf("failed to download Erlang from %s: %w", url, err))
+	}
+
+	manifest, err := readArchiveManifest(archive)
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress Erlang archive from %s: %w", url, err)
+	}
+
+	err = checkFreeSpace(parentDir, manifest.size)
+	if err != nil {
+		return "", err
+	}
+
+	stagingDir, err := os.MkdirTemp(parentDir, ".erlang-staging-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create staging directory in %s: %w", parentDir, err)
+	}
+	defer os.RemoveAll(stagingDir)
+
+	_, err = archive.Seek(0, io.SeekStart)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Erlang archive: %w", err)
+	}
+
+	err = vacation.NewArchive(archive).StripComponents(1).Decompress(stagingDir)
+	if err != nil {
+		return "", diskSpaceError(fmt.Errorf("failed to decompress Erlang archive to %s: %w", stagingDir, err))
+	}
+
+	err = manifest.verify(stagingDir)
+	if err != nil {
+		return "", fmt.Errorf("integrity check failed for Erlang archive from %s: %w", url, err)
+	}
+
+	err = smokeCheck(stagingDir)
+	if err != nil {
+		return "", fmt.Errorf("smoke check failed for Erlang archive from %s: %w", url, err)
+	}
+
+	// MkdirTemp creates the staging directory for its owner only, which
+	// would hide the runtime from a launch user other than the build user
+	err = os.Chmod(stagingDir, 0755)
+	if err != nil {
+		return "", fmt.Errorf("failed to set permissions of %s: %w", stagingDir, err)
+	}
+
+	err = os.RemoveAll(layerPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to clear %s: %w", layerPath, err)
+	}
+
+	err = os.Rename(stagingDir, layerPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to move Erlang installation into %s: %w", layerPath, err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// archiveManifest records the regular files of an archive, with the leading
+// path component stripped, so the extracted tree can be checked against it.
+type archiveManifest struct {
+	files map[string]int64
+	size  int64
+}
+
+func readArchiveManifest(file *os.File) (archiveManifest, error) {
+	_, err := file.Seek(0, io.SeekStart)
+	if err != nil {
+		return archiveManifest{}, err
 	}
 
-	err = vacation.NewArchive(resp.Body).StripComponents(1).Decompress(layerPath)
+	gz, err := gzip.NewReader(file)
 	if err != nil {
-		return fmt.Errorf("failed to decompress Erlang archive to %s: %w", layerPath, err)
+		return archiveManifest{}, err
+	}
+	defer gz.Close()
+
+	manifest := archiveManifest{files: map[string]int64{}}
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return archiveManifest{}, err
+		}
+
+		manifest.size += hdr.Size
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		_, name, found := strings.Cut(filepath.Clean(hdr.Name), string(filepath.Separator))
+		if !found {
+			continue
+		}
+		manifest.files[name] = hdr.Size
+	}
+
+	return manifest, nil
+}
+
+func (m archiveManifest) verify(dir string) error {
+	for name, size := range m.files {
+		info, err := os.Lstat(filepath.Join(dir, name))
+		if err != nil {
+			return fmt.Errorf("missing %s: %w", name, err)
+		}
+
+		if info.Size() != size {
+			return fmt.Errorf("%s has size %d, expected %d", name, info.Size(), size)
+		}
 	}
 
 	return nil
 }
+
+// smokeCheck ensures the extracted tree is a usable Erlang installation by
+// starting and stopping the runtime before it is promoted into the layer.
+func smokeCheck(dir string) error {
+	erl := filepath.Join(dir, "bin", "erl")
+
+	info, err := os.Stat(erl)
+	if err != nil {
+		return fmt.Errorf("bin/erl not found: %w", err)
+	}
+
+	if !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+		return fmt.Errorf("bin/erl is not an executable file")
+	}
+
+	buffer := bytes.NewBuffer(nil)
+	err = pexec.NewExecutable(erl).Execute(pexec.Execution{
+		Args:   []string{"-noshell", "-eval", "halt()."},
+		Dir:    dir,
+		Stdout: buffer,
+		Stderr: buffer,
+	})
+	if err != nil {
+		return fmt.Errorf("bin/erl failed to start: %w: %s", err, strings.TrimSpace(buffer.String()))
+	}
+
+	return nil
+}
+
+func checkFreeSpace(dir string, required int64) error {
+	var stat syscall.Statfs_t
+	err := syscall.Statfs(dir, &stat)
+	if err != nil {
+		return fmt.Errorf("failed to check free disk space in %s: %w", dir, err)
+	}
+
+	available := uint64(stat.Bavail) * uint64(stat.Bsize)
+	if uint64(required) > available {
+		return fmt.Errorf("not enough disk space to install Erlang in %s: %s required, %s available", dir, formatBytes(uint64(required)), formatBytes(available))
+	}
+
+	return nil
+}
+
+func diskSpaceError(err error) error {
+	if errors.Is(err, syscall.ENOSPC) {
+		return fmt.Errorf("ran out of disk space while installing Erlang: %w", err)
+	}
+	return err
+}
+
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -2,7 +2,10 @@ package erlang_test
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -18,6 +21,10 @@ import (
 func testErlangInstaller(t *testing.T, context spec.G, it spec.S) {
 	var Expect = NewWithT(t).Expect
 
+	// erlScript stands in for bin/erl and only succeeds when it is asked to
+	// start and stop the runtime
+	erlScript := []byte("#!/bin/sh\n[ \"$*\" = \"-noshell -eval halt().\" ]\n")
+
 	context("BuildDownloadURL", func() {
 		it("constructs the correct download URL", func() {
 			installer := erlang.NewErlangInstaller()
@@ -44,6 +51,7 @@ func testErlangInstaller(t *testing.T, context spec.G, it spec.S) {
 	context("Install", func() {
 		var (
 			installer erlang.ErlangInstaller
+			layersDir string
 			layerPath string
 			server    *httptest.Server
 		)
@@ -52,15 +60,18 @@ func testErlangInstaller(t *testing.T, context spec.G, it spec.S) {
 			installer = erlang.NewErlangInstaller()
 
 			var err error
-			layerPath, err = os.MkdirTemp("", "layer")
+			layersDir, err = os.MkdirTemp("", "layers")
 			Expect(err).NotTo(HaveOccurred())
+
+			layerPath = filepath.Join(layersDir, "erlang")
+			Expect(os.MkdirAll(layerPath, os.ModePerm)).To(Succeed())
 		})
 
 		it.After(func() {
 			if server != nil {
 				server.Close()
 			}
-			Expect(os.RemoveAll(layerPath)).To(Succeed())
+			Expect(os.RemoveAll(layersDir)).To(Succeed())
 		})
 
 		it("downloads and extracts Erlang", func() {
@@ -79,7 +90,7 @@ func testErlangInstaller(t *testing.T, context spec.G, it spec.S) {
 					Typeflag: tar.TypeDir,
 				})
 
-				content := []byte("test erlang binary")
+				content := erlScript
 				tw.WriteHeader(&tar.Header{
 					Name: "otp-28.1.1/bin/erl",
 					Mode: 0755,
@@ -88,7 +99,7 @@ func testErlangInstaller(t *testing.T, context spec.G, it spec.S) {
 				tw.Write(content)
 			}))
 
-			err := installer.Install(server.URL, layerPath)
+			_, err := installer.Install(server.URL, layerPath)
 			Expect(err).NotTo(HaveOccurred())
 
 			erlPath := filepath.Join(layerPath, "bin", "erl")
@@ -96,7 +107,45 @@ func testErlangInstaller(t *testing.T, context spec.G, it spec.S) {
 
 			content, err := os.ReadFile(erlPath)
 			Expect(err).NotTo(HaveOccurred())
-			Expect(string(content)).To(Equal("test erlang binary"))
+			Expect(content).To(Equal(erlScript))
+
+			info, err := os.Stat(layerPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0755)))
+
+			// the staging directory and download are cleaned up
+			entries, err := os.ReadDir(layersDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].Name()).To(Equal("erlang"))
+		})
+
+		it("returns the SHA-256 checksum of the archive", func() {
+			buffer := bytes.NewBuffer(nil)
+			gw := gzip.NewWriter(buffer)
+			tw := tar.NewWriter(gw)
+
+			content := erlScript
+			Expect(tw.WriteHeader(&tar.Header{
+				Name: "otp-28.1.1/bin/erl",
+				Mode: 0755,
+				Size: int64(len(content)),
+			})).To(Succeed())
+			_, err := tw.Write(content)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tw.Close()).To(Succeed())
+			Expect(gw.Close()).To(Succeed())
+
+			archive := buffer.Bytes()
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write(archive)
+			}))
+
+			checksum, err := installer.Install(server.URL, layerPath)
+			Expect(err).NotTo(HaveOccurred())
+
+			sum := sha256.Sum256(archive)
+			Expect(checksum).To(Equal(hex.EncodeToString(sum[:])))
 		})
 
 		context("failure cases", func() {
@@ -107,7 +156,7 @@ func testErlangInstaller(t *testing.T, context spec.G, it spec.S) {
 					url := server.URL
 					server.Close()
 
-					err := installer.Install(url, layerPath)
+					_, err := installer.Install(url, layerPath)
 					Expect(err).To(HaveOccurred())
 					Expect(err.Error()).To(ContainSubstring("failed to download Erlang"))
 				})
@@ -119,7 +168,7 @@ func testErlangInstaller(t *testing.T, context spec.G, it spec.S) {
 						w.WriteHeader(http.StatusNotFound)
 					}))
 
-					err := installer.Install(server.URL, layerPath)
+					_, err := installer.Install(server.URL, layerPath)
 					Expect(err).To(HaveOccurred())
 					Expect(err.Error()).To(ContainSubstring("received status code 404"))
 				})
@@ -136,11 +185,114 @@ func testErlangInstaller(t *testing.T, context spec.G, it spec.S) {
 						w.Write([]byte("invalid data"))
 					}))
 
-					err := installer.Install(server.URL, layerPath)
+					_, err := installer.Install(server.URL, layerPath)
 					Expect(err).To(HaveOccurred())
 					Expect(err.Error()).To(ContainSubstring("failed to decompress"))
 				})
 			})
+
+			context("when the archive does not contain bin/erl", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(layerPath, "existing"), []byte("keep"), 0644)).To(Succeed())
+
+					server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						gw := gzip.NewWriter(w)
+						defer gw.Close()
+
+						tw := tar.NewWriter(gw)
+						defer tw.Close()
+
+						content := []byte("not erlang")
+						tw.WriteHeader(&tar.Header{
+							Name: "otp-28.1.1/README",
+							Mode: 0644,
+							Size: int64(len(content)),
+						})
+						tw.Write(content)
+					}))
+				})
+
+				it("returns an error and leaves the layer untouched", func() {
+					_, err := installer.Install(server.URL, layerPath)
+					Expect(err).To(MatchError(ContainSubstring("smoke check failed")))
+					Expect(err).To(MatchError(ContainSubstring("bin/erl not found")))
+
+					Expect(filepath.Join(layerPath, "existing")).To(BeARegularFile())
+					Expect(filepath.Join(layerPath, "README")).NotTo(BeAnExistingFile())
+
+					entries, err := os.ReadDir(layersDir)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(entries).To(HaveLen(1))
+				})
+			})
+
+			context("when bin/erl is not executable", func() {
+				it.Before(func() {
+					server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						gw := gzip.NewWriter(w)
+						defer gw.Close()
+
+						tw := tar.NewWriter(gw)
+						defer tw.Close()
+
+						content := []byte("test erlang binary")
+						tw.WriteHeader(&tar.Header{
+							Name: "otp-28.1.1/bin/erl",
+							Mode: 0644,
+							Size: int64(len(content)),
+						})
+						tw.Write(content)
+					}))
+				})
+
+				it("returns an error", func() {
+					_, err := installer.Install(server.URL, layerPath)
+					Expect(err).To(MatchError(ContainSubstring("bin/erl is not an executable file")))
+				})
+			})
+
+			context("when bin/erl fails to start", func() {
+				it.Before(func() {
+					server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						gw := gzip.NewWriter(w)
+						defer gw.Close()
+
+						tw := tar.NewWriter(gw)
+						defer tw.Close()
+
+						content := []byte("#!/bin/sh\necho 'erts is corrupt'\nexit 1\n")
+						tw.WriteHeader(&tar.Header{
+							Name: "otp-28.1.1/bin/erl",
+							Mode: 0755,
+							Size: int64(len(content)),
+						})
+						tw.Write(content)
+					}))
+				})
+
+				it("returns an error and leaves the layer untouched", func() {
+					_, err := installer.Install(server.URL, layerPath)
+					Expect(err).To(MatchError(ContainSubstring("smoke check failed")))
+					Expect(err).To(MatchError(ContainSubstring("erts is corrupt")))
+
+					Expect(filepath.Join(layerPath, "bin", "erl")).NotTo(BeAnExistingFile())
+				})
+			})
+
+			context("when the download is larger than the free disk space", func() {
+				it.Before(func() {
+					server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						w.Header().Set("Content-Length", "1152921504606846976")
+						w.WriteHeader(http.StatusOK)
+					}))
+				})
+
+				it("returns an error before downloading", func() {
+					_, err := installer.Install(server.URL, layerPath)
+					Expect(err).To(MatchError(ContainSubstring("not enough disk space to install Erlang")))
+					Expect(err).To(MatchError(ContainSubstring("1.0 EiB required")))
+				})
+			})
 		})
 	})
 }
@@ -0,0 +1,135 @@
+package erlang
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	ErlangMk = "erlang.mk"
+
+	ErlangMkCacheLayerName = "erlang-mk-cache"
+
+	// DepsSpecSHA256Key is the metadata key of the digest of the dependency
+	// declarations in the Makefile of an erlang.mk project.
+	DepsSpecSHA256Key = "deps-spec-sha256"
+
+	DefaultErlangMkTargets = "rel"
+
+	// ErlangMkReleaseDir is where erlang.mk's relx plugin writes releases
+	// unless RELX_OUTPUT_DIR says otherwise.
+	ErlangMkReleaseDir = "_rel"
+)
+
+// erlangMkInclude matches the include line of an erlang.mk project, including
+// the generated form "include $(if $(ERLANG_MK_FILENAME),$(ERLANG_MK_FILENAME),erlang.mk)".
+var erlangMkInclude = regexp.MustCompile(`^\s*[-s]?include\s+.*\berlang\.mk\b`)
+
+// erlangMkDepsVariable matches the Makefile assignments that declare the
+// dependencies of an erlang.mk project and where they come from.
+var erlangMkDepsVariable = regexp.MustCompile(`^\s*(?:override\s+)?(?:DEPS|BUILD_DEPS|LOCAL_DEPS|DEP_PLUGINS|DEP_EARLY_PLUGINS|dep_\S+)\s*(?:[:?+]?=)`)
+
+// UsesErlangMk reports whether the Makefile in workingDir includes erlang.mk.
+func UsesErlangMk(workingDir string) (bool, error) {
+	file, err := os.Open(filepath.Join(workingDir, "Makefile"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to open Makefile: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if erlangMkInclude.MatchString(scanner.Text()) {
+			return true, nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("failed to read Makefile: %w", err)
+	}
+
+	return false, nil
+}
+
+// ErlangMkDepsDigest returns the SHA-256, as lowercase hex, of the
+// dependency declarations in the Makefile in workingDir: the DEPS,
+// BUILD_DEPS, LOCAL_DEPS and plugin lists and the dep_* fetch methods. It
+// returns an empty string when there is no Makefile.
+func ErlangMkDepsDigest(workingDir string) (string, error) {
+	file, err := os.Open(filepath.Join(workingDir, "Makefile"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to open Makefile: %w", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+
+	var line string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// join continued lines so that multi-line lists are compared whole
+		line += scanner.Text()
+		if strings.HasSuffix(line, "\\") {
+			line = strings.TrimSuffix(line, "\\") + " "
+			continue
+		}
+
+		if erlangMkDepsVariable.MatchString(line) {
+			fmt.Fprintln(hash, strings.Join(strings.Fields(line), " "))
+		}
+		line = ""
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read Makefile: %w", err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// ErlangMkTargets returns the make targets from BP_ERLANG_MK_TARGETS, which
+// defaults to "rel".
+func ErlangMkTargets() []string {
+	targets := strings.Fields(os.Getenv("BP_ERLANG_MK_TARGETS"))
+	if len(targets) == 0 {
+		return []string{DefaultErlangMkTargets}
+	}
+	return targets
+}
+
+// ErlangMkReleases lists the releases erlang.mk built. Names come from
+// relx.config when there is one, otherwise from the directories under _rel.
+// It reports false when the project builds no release.
+func ErlangMkReleases(workingDir string) (RelxConfig, bool, error) {
+	config, ok, err := ParseRelxFile(filepath.Join(workingDir, "relx.config"))
+	if err != nil || ok {
+		return config, ok, err
+	}
+
+	config = RelxConfig{Source: ErlangMkReleaseDir}
+
+	entries, err := os.ReadDir(filepath.Join(workingDir, ErlangMkReleaseDir))
+	if err != nil && !os.IsNotExist(err) {
+		return RelxConfig{}, false, fmt.Errorf("failed to list releases: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			config.Releases = append(config.Releases, RelxRelease{Name: entry.Name()})
+		}
+	}
+
+	return config, len(config.Releases) > 0, nil
+}
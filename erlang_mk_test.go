@@ -0,0 +1,141 @@
+package erlang_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SnakeDoc/erlang-cnb"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testErlangMk(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		workingDir string
+	)
+
+	it.Before(func() {
+		workingDir = t.TempDir()
+	})
+
+	context("UsesErlangMk", func() {
+		it("recognises a plain include", func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "Makefile"), []byte("PROJECT = hello\ninclude erlang.mk\n"), 0644)).To(Succeed())
+
+			ok, err := erlang.UsesErlangMk(workingDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+		})
+
+		it("recognises the generated include", func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "Makefile"), []byte("include $(if $(ERLANG_MK_FILENAME),$(ERLANG_MK_FILENAME),erlang.mk)\n"), 0644)).To(Succeed())
+
+			ok, err := erlang.UsesErlangMk(workingDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+		})
+
+		it("ignores other Makefiles", func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "Makefile"), []byte("# see erlang.mk\nall:\n\terlc src/*.erl\n"), 0644)).To(Succeed())
+
+			ok, err := erlang.UsesErlangMk(workingDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+
+		it("reports false without a Makefile", func() {
+			ok, err := erlang.UsesErlangMk(workingDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	context("ErlangMkDepsDigest", func() {
+		var makefile string
+
+		it.Before(func() {
+			makefile = "PROJECT = hello\nDEPS = cowboy \\\n\tjsx\ndep_cowboy = hex 2.10.0\n\ninclude erlang.mk\n"
+			Expect(os.WriteFile(filepath.Join(workingDir, "Makefile"), []byte(makefile), 0644)).To(Succeed())
+		})
+
+		it("ignores everything but the dependency declarations", func() {
+			digest, err := erlang.ErlangMkDepsDigest(workingDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(digest).To(HaveLen(64))
+
+			Expect(os.WriteFile(filepath.Join(workingDir, "Makefile"), []byte("PROJECT = renamed\nPROJECT_VERSION = 2.0.0\n"+makefile), 0644)).To(Succeed())
+			Expect(erlang.ErlangMkDepsDigest(workingDir)).To(Equal(digest))
+		})
+
+		it("changes with the dependency list, continued lines included", func() {
+			digest, err := erlang.ErlangMkDepsDigest(workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.WriteFile(filepath.Join(workingDir, "Makefile"), []byte("PROJECT = hello\nDEPS = cowboy \\\n\tjsone\ndep_cowboy = hex 2.10.0\n\ninclude erlang.mk\n"), 0644)).To(Succeed())
+			Expect(erlang.ErlangMkDepsDigest(workingDir)).NotTo(Equal(digest))
+		})
+
+		it("changes with the fetch method of a dependency", func() {
+			digest, err := erlang.ErlangMkDepsDigest(workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.WriteFile(filepath.Join(workingDir, "Makefile"), []byte("PROJECT = hello\nDEPS = cowboy \\\n\tjsx\ndep_cowboy = hex 2.12.0\n\ninclude erlang.mk\n"), 0644)).To(Succeed())
+			Expect(erlang.ErlangMkDepsDigest(workingDir)).NotTo(Equal(digest))
+		})
+
+		it("returns an empty digest without a Makefile", func() {
+			Expect(os.Remove(filepath.Join(workingDir, "Makefile"))).To(Succeed())
+
+			digest, err := erlang.ErlangMkDepsDigest(workingDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(digest).To(BeEmpty())
+		})
+	})
+
+	context("ErlangMkTargets", func() {
+		it.After(func() {
+			Expect(os.Unsetenv("BP_ERLANG_MK_TARGETS")).To(Succeed())
+		})
+
+		it("defaults to rel", func() {
+			Expect(erlang.ErlangMkTargets()).To(Equal([]string{"rel"}))
+		})
+
+		it("reads BP_ERLANG_MK_TARGETS", func() {
+			Expect(os.Setenv("BP_ERLANG_MK_TARGETS", " app  rel ")).To(Succeed())
+			Expect(erlang.ErlangMkTargets()).To(Equal([]string{"app", "rel"}))
+		})
+	})
+
+	context("ErlangMkReleases", func() {
+		it("reads the releases from relx.config", func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "relx.config"), []byte(`{release, {hello, "1.0.0"}, [hello]}.`), 0644)).To(Succeed())
+
+			config, ok, err := erlang.ErlangMkReleases(workingDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(config.Releases).To(Equal([]erlang.RelxRelease{{Name: "hello", Version: "1.0.0"}}))
+		})
+
+		it("falls back to the directories under _rel", func() {
+			Expect(os.MkdirAll(filepath.Join(workingDir, "_rel", "hello"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "_rel", "hello.tar.gz"), nil, 0644)).To(Succeed())
+
+			config, ok, err := erlang.ErlangMkReleases(workingDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(config.Releases).To(Equal([]erlang.RelxRelease{{Name: "hello"}}))
+			Expect(config.Source).To(Equal("_rel"))
+		})
+
+		it("reports false when there is no release", func() {
+			_, ok, err := erlang.ErlangMkReleases(workingDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+	})
+}
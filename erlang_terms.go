@@ -0,0 +1,426 @@
+package erlang
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Atom is an Erlang atom such as ok or 'quoted atom'.
+type Atom string
+
+// Tuple is an Erlang tuple.
+type Tuple []any
+
+// List is an Erlang list. Double-quoted character lists are returned as Go
+// strings instead.
+type List []any
+
+// Binary is an Erlang binary. Both <<"text">> and <<1,2,3>> forms are
+// returned as Binary.
+type Binary string
+
+// MapEntry is a single association of an Erlang map.
+type MapEntry struct {
+	Key   any
+	Value any
+}
+
+// Map is an Erlang map, kept in source order.
+type Map []MapEntry
+
+// ParseTerms parses the dot-terminated Erlang terms in src, as read by
+// file:consult/1. Integers are returned as int64 and floats as float64.
+func ParseTerms(src string) ([]any, error) {
+	p := &termParser{src: src}
+
+	var terms []any
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return terms, nil
+		}
+
+		term, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+
+		p.skipSpace()
+		if !p.consume('.') {
+			return nil, p.errorf("expected '.' after term")
+		}
+
+		terms = append(terms, term)
+	}
+}
+
+type termParser struct {
+	src string
+	pos int
+}
+
+func (p *termParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *termParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *termParser) consume(c byte) bool {
+	if p.peek() == c && !p.eof() {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *termParser) errorf(format string, args ...any) error {
+	line := strings.Count(p.src[:min(p.pos, len(p.src))], "\n") + 1
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func (p *termParser) skipSpace() {
+	for !p.eof() {
+		c := p.peek()
+		switch {
+		case c == '%':
+			for !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+		case c == ' ', c == '\t', c == '\n', c == '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *termParser) term() (any, error) {
+	p.skipSpace()
+	if p.eof() {
+		return nil, p.errorf("unexpected end of input")
+	}
+
+	c := p.peek()
+	switch {
+	case c == '{':
+		p.pos++
+		items, err := p.sequence('}')
+		return Tuple(items), err
+
+	case c == '[':
+		p.pos++
+		items, err := p.sequence(']')
+		return List(items), err
+
+	case c == '#':
+		p.pos++
+		if !p.consume('{') {
+			return nil, p.errorf("expected '{' after '#'")
+		}
+		return p.mapEntries()
+
+	case c == '<' && strings.HasPrefix(p.src[p.pos:], "<<"):
+		p.pos += 2
+		return p.binary()
+
+	case c == '"':
+		return p.quoted('"')
+
+	case c == '\'':
+		s, err := p.quoted('\'')
+		return Atom(s), err
+
+	case c == '$':
+		p.pos++
+		r, err := p.char()
+		return int64(r), err
+
+	case c == '-' || c == '+' || (c >= '0' && c <= '9'):
+		return p.number()
+
+	case c >= 'a' && c <= 'z':
+		start := p.pos
+		for !p.eof() && isAtomChar(p.peek()) {
+			p.pos++
+		}
+		return Atom(p.src[start:p.pos]), nil
+	}
+
+	return nil, p.errorf("unexpected character %q", c)
+}
+
+func (p *termParser) sequence(end byte) ([]any, error) {
+	items := []any{}
+
+	p.skipSpace()
+	if p.consume(end) {
+		return items, nil
+	}
+
+	for {
+		item, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+
+		p.skipSpace()
+		switch {
+		case p.consume(','):
+		case p.consume(end):
+			return items, nil
+		default:
+			return nil, p.errorf("expected ',' or '%c'", end)
+		}
+	}
+}
+
+func (p *termParser) mapEntries() (Map, error) {
+	entries := Map{}
+
+	p.skipSpace()
+	if p.consume('}') {
+		return entries, nil
+	}
+
+	for {
+		key, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+
+		p.skipSpace()
+		if !strings.HasPrefix(p.src[p.pos:], "=>") && !strings.HasPrefix(p.src[p.pos:], ":=") {
+			return nil, p.errorf("expected '=>' in map")
+		}
+		p.pos += 2
+
+		value, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, MapEntry{Key: key, Value: value})
+
+		p.skipSpace()
+		switch {
+		case p.consume(','):
+		case p.consume('}'):
+			return entries, nil
+		default:
+			return nil, p.errorf("expected ',' or '}' in map")
+		}
+	}
+}
+
+func (p *termParser) binary() (Binary, error) {
+	var b strings.Builder
+
+	p.skipSpace()
+	if strings.HasPrefix(p.src[p.pos:], ">>") {
+		p.pos += 2
+		return "", nil
+	}
+
+	for {
+		p.skipSpace()
+		if p.peek() == '"' {
+			s, err := p.quoted('"')
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(s)
+		} else {
+			n, err := p.number()
+			if err != nil {
+				return "", err
+			}
+			i, ok := n.(int64)
+			if !ok || i < 0 || i > 255 {
+				return "", p.errorf("invalid byte in binary")
+			}
+			b.WriteByte(byte(i))
+		}
+
+		p.skipSpace()
+		switch {
+		case p.consume(','):
+		case strings.HasPrefix(p.src[p.pos:], ">>"):
+			p.pos += 2
+			return Binary(b.String()), nil
+		default:
+			return "", p.errorf("expected ',' or '>>' in binary")
+		}
+	}
+}
+
+func (p *termParser) quoted(quote byte) (string, error) {
+	p.pos++
+
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated quoted text")
+		}
+
+		c := p.peek()
+		if c == quote {
+			p.pos++
+			return b.String(), nil
+		}
+
+		if c != '\\' {
+			// copy raw bytes so UTF-8 text survives unchanged
+			b.WriteByte(c)
+			p.pos++
+			continue
+		}
+
+		r, err := p.char()
+		if err != nil {
+			return "", err
+		}
+		b.WriteRune(r)
+	}
+}
+
+func (p *termParser) char() (rune, error) {
+	if p.eof() {
+		return 0, p.errorf("unexpected end of input")
+	}
+
+	c := p.src[p.pos]
+	p.pos++
+	if c != '\\' {
+		return rune(c), nil
+	}
+
+	if p.eof() {
+		return 0, p.errorf("unexpected end of input")
+	}
+
+	c = p.src[p.pos]
+	p.pos++
+	switch c {
+	case 'n':
+		return '\n', nil
+	case 't':
+		return '\t', nil
+	case 'r':
+		return '\r', nil
+	case 's':
+		return ' ', nil
+	case 'e':
+		return 0x1b, nil
+	case '0', '1', '2', '3', '4', '5', '6', '7':
+		start := p.pos - 1
+		for p.pos-start < 3 && !p.eof() && p.peek() >= '0' && p.peek() <= '7' {
+			p.pos++
+		}
+		n, _ := strconv.ParseInt(p.src[start:p.pos], 8, 32)
+		return rune(n), nil
+	}
+
+	return rune(c), nil
+}
+
+func (p *termParser) number() (any, error) {
+	start := p.pos
+	if p.peek() == '-' || p.peek() == '+' {
+		p.pos++
+	}
+
+	for !p.eof() && (unicode.IsDigit(rune(p.peek())) || p.peek() == '_') {
+		p.pos++
+	}
+
+	if p.peek() == '#' {
+		base, err := strconv.Atoi(strings.TrimLeft(p.src[start:p.pos], "+-"))
+		if err != nil {
+			return nil, p.errorf("invalid integer base")
+		}
+		p.pos++
+
+		digits := p.pos
+		for !p.eof() && isAtomChar(p.peek()) {
+			p.pos++
+		}
+
+		n, err := strconv.ParseInt(p.src[digits:p.pos], base, 64)
+		if err != nil {
+			return nil, p.errorf("invalid integer %q", p.src[start:p.pos])
+		}
+		if p.src[start] == '-' {
+			n = -n
+		}
+		return n, nil
+	}
+
+	isFloat := false
+	if p.peek() == '.' && p.pos+1 < len(p.src) && unicode.IsDigit(rune(p.src[p.pos+1])) {
+		isFloat = true
+		p.pos++
+		for !p.eof() && unicode.IsDigit(rune(p.peek())) {
+			p.pos++
+		}
+
+		if p.peek() == 'e' || p.peek() == 'E' {
+			p.pos++
+			if p.peek() == '-' || p.peek() == '+' {
+				p.pos++
+			}
+			for !p.eof() && unicode.IsDigit(rune(p.peek())) {
+				p.pos++
+			}
+		}
+	}
+
+	text := strings.ReplaceAll(p.src[start:p.pos], "_", "")
+	if isFloat {
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, p.errorf("invalid float %q", text)
+		}
+		return f, nil
+	}
+
+	n, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return nil, p.errorf("invalid integer %q", text)
+	}
+	return n, nil
+}
+
+func isAtomChar(c byte) bool {
+	return c == '_' || c == '@' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// proplistValue returns the value of the first {Key, Value} tuple in list.
+func proplistValue(list List, key Atom) (any, bool) {
+	for _, item := range list {
+		tuple, ok := item.(Tuple)
+		if ok && len(tuple) == 2 && tuple[0] == key {
+			return tuple[1], true
+		}
+	}
+	return nil, false
+}
+
+// atomList converts a list of atoms to strings, ignoring other elements.
+func atomList(value any) []string {
+	list, _ := value.(List)
+
+	var atoms []string
+	for _, item := range list {
+		if atom, ok := item.(Atom); ok {
+			atoms = append(atoms, string(atom))
+		}
+	}
+	return atoms
+}
@@ -0,0 +1,72 @@
+package erlang_test
+
+import (
+	"testing"
+
+	"github.com/SnakeDoc/erlang-cnb"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testErlangTerms(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	it("parses a sequence of terms", func() {
+		terms, err := erlang.ParseTerms(`
+			% a comment
+			{application, stdlib, [{vsn, "7.1"}, {applications, [kernel]}]}.
+			'quoted atom'.
+			[1, -2, 3.5, 16#ff, $a].
+		`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(terms).To(Equal([]any{
+			erlang.Tuple{
+				erlang.Atom("application"),
+				erlang.Atom("stdlib"),
+				erlang.List{
+					erlang.Tuple{erlang.Atom("vsn"), "7.1"},
+					erlang.Tuple{erlang.Atom("applications"), erlang.List{erlang.Atom("kernel")}},
+				},
+			},
+			erlang.Atom("quoted atom"),
+			erlang.List{int64(1), int64(-2), 3.5, int64(255), int64('a')},
+		}))
+	})
+
+	it("parses binaries and maps", func() {
+		terms, err := erlang.ParseTerms(`{<<"cowboy">>, <<1,2,255>>, <<>>, #{<<"k">> => v}}.`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(terms).To(Equal([]any{
+			erlang.Tuple{
+				erlang.Binary("cowboy"),
+				erlang.Binary("\x01\x02\xff"),
+				erlang.Binary(""),
+				erlang.Map{{Key: erlang.Binary("k"), Value: erlang.Atom("v")}},
+			},
+		}))
+	})
+
+	it("handles escapes and UTF-8 text in strings", func() {
+		terms, err := erlang.ParseTerms(`"tab\there \"quoted\" héllo".`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(terms).To(Equal([]any{"tab\there \"quoted\" héllo"}))
+	})
+
+	context("failure cases", func() {
+		it("reports a missing terminating dot", func() {
+			_, err := erlang.ParseTerms(`{ok, 1}`)
+			Expect(err).To(MatchError("line 1: expected '.' after term"))
+		})
+
+		it("reports unterminated strings", func() {
+			_, err := erlang.ParseTerms("\n\"abc")
+			Expect(err).To(MatchError(ContainSubstring("unterminated quoted text")))
+		})
+
+		it("reports the line of an unexpected character", func() {
+			_, err := erlang.ParseTerms("{ok,\n ;}.")
+			Expect(err).To(MatchError("line 2: unexpected character ';'"))
+		})
+	})
+}
@@ -2,6 +2,8 @@ package erlang
 
 import (
 	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
@@ -14,9 +16,17 @@ const (
 	BuildsURLTemplate = "https://builds.hex.pm/builds/otp/%s/%s/builds.txt"
 )
 
-func ResolveVersion(arch, ubuntuVersion string) (string, error) {
+// IndexDigest identifies the copy of builds.txt a version was resolved from.
+type IndexDigest struct {
+	URL    string
+	SHA256 string
+}
+
+// ResolveVersion returns BP_ERLANG_VERSION when it is set, or else the latest
+// stable release listed in builds.txt together with the digest of that file.
+func ResolveVersion(arch, ubuntuVersion string) (string, IndexDigest, error) {
 	if version := os.Getenv("BP_ERLANG_VERSION"); version != "" {
-		return version, nil
+		return version, IndexDigest{}, nil
 	}
 
 	return fetchLatestVersion(arch, ubuntuVersion)
@@ -26,24 +36,95 @@ func parseOTPVersion(version string) string {
 	return strings.TrimPrefix(version, "OTP-")
 }
 
-func fetchLatestVersion(arch, ubuntuVersion string) (string, error) {
+func fetchLatestVersion(arch, ubuntuVersion string) (string, IndexDigest, error) {
 	url := fmt.Sprintf(BuildsURLTemplate, arch, ubuntuVersion)
 
 	resp, err := http.Get(url)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch latest Erlang version from %s: %w", url, err)
+		return "", IndexDigest{}, fmt.Errorf("failed to fetch latest Erlang version from %s: %w", url, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("failed to fetch latest Erlang version from %s: received status code %d", url, resp.StatusCode)
+		return "", IndexDigest{}, fmt.Errorf("failed to fetch latest Erlang version from %s: received status code %d", url, resp.StatusCode)
+	}
+
+	hash := sha256.New()
+	version, err := ParseLatestStableVersion(io.TeeReader(resp.Body, hash))
+	if err != nil {
+		return "", IndexDigest{}, err
 	}
 
-	version, err := ParseLatestStableVersion(resp.Body)
+	// hash whatever the scanner left unread
+	_, err = io.Copy(hash, resp.Body)
+	if err != nil {
+		return "", IndexDigest{}, fmt.Errorf("failed to read %s: %w", url, err)
+	}
+
+	return parseOTPVersion(version), IndexDigest{URL: url, SHA256: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// BuildsIndex lists the OTP releases published on builds.hex.pm.
+type BuildsIndex struct{}
+
+func NewBuildsIndex() BuildsIndex {
+	return BuildsIndex{}
+}
+
+// ListVersions returns every stable release available for the given
+// architecture and Ubuntu version, without the "OTP-" prefix.
+func (i BuildsIndex) ListVersions(arch, ubuntuVersion string) ([]string, error) {
+	url := fmt.Sprintf(BuildsURLTemplate, arch, ubuntuVersion)
+
+	resp, err := http.Get(url)
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("failed to fetch Erlang versions from %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch Erlang versions from %s: received status code %d", url, resp.StatusCode)
+	}
+
+	return ParseStableVersions(resp.Body)
+}
+
+// ParseStableVersions returns the stable releases listed in a builds.txt
+// file, without the "OTP-" prefix, in the order they appear.
+func ParseStableVersions(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+
+	var versions []string
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || !isStableVersion(fields[0]) {
+			continue
+		}
+		versions = append(versions, parseOTPVersion(fields[0]))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading version data: %w", err)
+	}
+
+	return versions, nil
+}
+
+// newestVersion returns the highest of versions accepted by keep, or "" when
+// none is.
+func newestVersion(versions []string, keep func(string) bool) string {
+	var newest []int
+	var newestText string
+	for _, version := range versions {
+		ver, err := parseVersion(version)
+		if err != nil || !keep(version) {
+			continue
+		}
+		if newest == nil || compareVersions(ver, newest) > 0 {
+			newest, newestText = ver, version
+		}
 	}
-	return parseOTPVersion(version), nil
+	return newestText
 }
 
 // stable releases have the format "OTP-x.y.z.*"
@@ -111,6 +111,20 @@ func testErlangVersionResolver(t *testing.T, context spec.G, it spec.S) {
 		})
 	})
 
+	context("ParseStableVersions", func() {
+		it("lists every stable version without the OTP prefix", func() {
+			input := `	OTP-27.2 hash1 2024-12-11T10:30:23Z
+					OTP-28.0-rc1 hash2 2025-02-12T10:30:23Z
+					maint-27 hash3 2025-05-21T08:33:49Z
+					OTP-28.1.1 hash4 2025-10-20T15:23:31Z
+				`
+
+			result, err := erlang.ParseStableVersions(strings.NewReader(input))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal([]string{"27.2", "28.1.1"}))
+		})
+	})
+
 	context("ResolveVersion", func() {
 		context("when BP_ERLANG_VERSION is set", func() {
 			it.Before(func() {
@@ -121,9 +135,10 @@ func testErlangVersionResolver(t *testing.T, context spec.G, it spec.S) {
 			})
 
 			it("returns the normalized version from the environment variable", func() {
-				result, err := erlang.ResolveVersion("amd64", "ubuntu-24.04")
+				result, index, err := erlang.ResolveVersion("amd64", "ubuntu-24.04")
 				Expect(err).NotTo(HaveOccurred())
 				Expect(result).To(Equal("28.1.1"))
+				Expect(index).To(Equal(erlang.IndexDigest{}))
 			})
 		})
 	})
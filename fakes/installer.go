@@ -24,9 +24,10 @@ type Installer struct {
 			LayerPath string
 		}
 		Returns struct {
-			Error error
+			Sha256 string
+			Err    error
 		}
-		Stub func(string, string) error
+		Stub func(string, string) (string, error)
 	}
 }
 
@@ -42,7 +43,7 @@ func (f *Installer) BuildDownloadURL(param1 string, param2 string, param3 string
 	}
 	return f.BuildDownloadURLCall.Returns.String
 }
-func (f *Installer) Install(param1 string, param2 string) error {
+func (f *Installer) Install(param1 string, param2 string) (string, error) {
 	f.InstallCall.mutex.Lock()
 	defer f.InstallCall.mutex.Unlock()
 	f.InstallCall.CallCount++
@@ -51,5 +52,5 @@ func (f *Installer) Install(param1 string, param2 string) error {
 	if f.InstallCall.Stub != nil {
 		return f.InstallCall.Stub(param1, param2)
 	}
-	return f.InstallCall.Returns.Error
+	return f.InstallCall.Returns.Sha256, f.InstallCall.Returns.Err
 }
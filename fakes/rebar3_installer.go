@@ -0,0 +1,56 @@
+package fakes
+
+import (
+	"sync"
+
+	"github.com/SnakeDoc/erlang-cnb"
+)
+
+type Rebar3Installer struct {
+	InstallCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Release   erlang.Rebar3Release
+			LayerPath string
+		}
+		Returns struct {
+			Error error
+		}
+		Stub func(erlang.Rebar3Release, string) error
+	}
+	ResolveReleaseCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Version string
+		}
+		Returns struct {
+			Rebar3Release erlang.Rebar3Release
+			Error         error
+		}
+		Stub func(string) (erlang.Rebar3Release, error)
+	}
+}
+
+func (f *Rebar3Installer) Install(param1 erlang.Rebar3Release, param2 string) error {
+	f.InstallCall.mutex.Lock()
+	defer f.InstallCall.mutex.Unlock()
+	f.InstallCall.CallCount++
+	f.InstallCall.Receives.Release = param1
+	f.InstallCall.Receives.LayerPath = param2
+	if f.InstallCall.Stub != nil {
+		return f.InstallCall.Stub(param1, param2)
+	}
+	return f.InstallCall.Returns.Error
+}
+func (f *Rebar3Installer) ResolveRelease(param1 string) (erlang.Rebar3Release, error) {
+	f.ResolveReleaseCall.mutex.Lock()
+	defer f.ResolveReleaseCall.mutex.Unlock()
+	f.ResolveReleaseCall.CallCount++
+	f.ResolveReleaseCall.Receives.Version = param1
+	if f.ResolveReleaseCall.Stub != nil {
+		return f.ResolveReleaseCall.Stub(param1)
+	}
+	return f.ResolveReleaseCall.Returns.Rebar3Release, f.ResolveReleaseCall.Returns.Error
+}
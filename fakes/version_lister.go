@@ -0,0 +1,31 @@
+package fakes
+
+import "sync"
+
+type VersionLister struct {
+	ListVersionsCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Arch          string
+			UbuntuVersion string
+		}
+		Returns struct {
+			StringSlice []string
+			Error       error
+		}
+		Stub func(string, string) ([]string, error)
+	}
+}
+
+func (f *VersionLister) ListVersions(param1 string, param2 string) ([]string, error) {
+	f.ListVersionsCall.mutex.Lock()
+	defer f.ListVersionsCall.mutex.Unlock()
+	f.ListVersionsCall.CallCount++
+	f.ListVersionsCall.Receives.Arch = param1
+	f.ListVersionsCall.Receives.UbuntuVersion = param2
+	if f.ListVersionsCall.Stub != nil {
+		return f.ListVersionsCall.Stub(param1, param2)
+	}
+	return f.ListVersionsCall.Returns.StringSlice, f.ListVersionsCall.Returns.Error
+}
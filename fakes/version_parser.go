@@ -3,6 +3,18 @@ package fakes
 import "sync"
 
 type VersionParser struct {
+	ParseRebar3VersionCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Path string
+		}
+		Returns struct {
+			Version string
+			Err     error
+		}
+		Stub func(string) (string, error)
+	}
 	ParseVersionCall struct {
 		mutex     sync.Mutex
 		CallCount int
@@ -17,6 +29,16 @@ type VersionParser struct {
 	}
 }
 
+func (f *VersionParser) ParseRebar3Version(param1 string) (string, error) {
+	f.ParseRebar3VersionCall.mutex.Lock()
+	defer f.ParseRebar3VersionCall.mutex.Unlock()
+	f.ParseRebar3VersionCall.CallCount++
+	f.ParseRebar3VersionCall.Receives.Path = param1
+	if f.ParseRebar3VersionCall.Stub != nil {
+		return f.ParseRebar3VersionCall.Stub(param1)
+	}
+	return f.ParseRebar3VersionCall.Returns.Version, f.ParseRebar3VersionCall.Returns.Err
+}
 func (f *VersionParser) ParseVersion(param1 string) (string, error) {
 	f.ParseVersionCall.mutex.Lock()
 	defer f.ParseVersionCall.mutex.Unlock()
@@ -0,0 +1,269 @@
+package erlang
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// GitCredentialsBindingType is the type of the bindings that hold
+	// credentials for git dependencies fetched over HTTPS, in the "credentials"
+	// entry and in the format of "git credential", e.g.
+	//
+	//	protocol=https
+	//	host=github.com
+	//	username=x-access-token
+	//	password=<token>
+	GitCredentialsBindingType = "git-credentials"
+
+	// SSHBindingType is the type of the bindings that hold an SSH key for git
+	// dependencies fetched over SSH, in the "ssh-privatekey" entry, and
+	// optionally the host keys to trust in "known-hosts".
+	SSHBindingType = "ssh"
+)
+
+// GitCredential is a username and password for the git host at
+// Protocol://Host/Path. Username and Password must never be logged.
+type GitCredential struct {
+	Protocol string
+	Host     string
+	Path     string
+	Username string
+	Password string
+
+	// Binding names the binding the credential came from.
+	Binding string
+}
+
+// SSHKey is a private key for git over SSH. PrivateKey must never be logged.
+type SSHKey struct {
+	PrivateKey string
+	KnownHosts string
+
+	// Binding names the binding the key came from.
+	Binding string
+}
+
+// GitAuth is the git authentication read from git-credentials and ssh
+// bindings.
+type GitAuth struct {
+	Credentials []GitCredential
+	Keys        []SSHKey
+}
+
+// LoadGitAuth reads every git-credentials and ssh binding under platformDir.
+func LoadGitAuth(resolver BindingResolver, platformDir string) (GitAuth, error) {
+	var auth GitAuth
+
+	bindings, err := resolver.Resolve(GitCredentialsBindingType, "", platformDir)
+	if err != nil {
+		return GitAuth{}, fmt.Errorf("failed to resolve %s bindings: %w", GitCredentialsBindingType, err)
+	}
+
+	for _, binding := range bindings {
+		entry, ok := binding.Entries["credentials"]
+		if !ok {
+			return GitAuth{}, fmt.Errorf("binding %s has no credentials entry", binding.Name)
+		}
+
+		content, err := entry.ReadString()
+		if err != nil {
+			return GitAuth{}, fmt.Errorf("failed to read credentials from binding %s: %w", binding.Name, err)
+		}
+
+		credential, err := ParseGitCredential(content)
+		if err != nil {
+			return GitAuth{}, fmt.Errorf("invalid credentials in binding %s: %w", binding.Name, err)
+		}
+		credential.Binding = binding.Name
+
+		auth.Credentials = append(auth.Credentials, credential)
+	}
+
+	bindings, err = resolver.Resolve(SSHBindingType, "", platformDir)
+	if err != nil {
+		return GitAuth{}, fmt.Errorf("failed to resolve %s bindings: %w", SSHBindingType, err)
+	}
+
+	for _, binding := range bindings {
+		entry, ok := binding.Entries["ssh-privatekey"]
+		if !ok {
+			return GitAuth{}, fmt.Errorf("binding %s has no ssh-privatekey entry", binding.Name)
+		}
+
+		privateKey, err := entry.ReadString()
+		if err != nil {
+			return GitAuth{}, fmt.Errorf("failed to read ssh-privatekey from binding %s: %w", binding.Name, err)
+		}
+
+		key := SSHKey{PrivateKey: privateKey, Binding: binding.Name}
+
+		if entry, ok := binding.Entries["known-hosts"]; ok {
+			key.KnownHosts, err = entry.ReadString()
+			if err != nil {
+				return GitAuth{}, fmt.Errorf("failed to read known-hosts from binding %s: %w", binding.Name, err)
+			}
+		}
+
+		auth.Keys = append(auth.Keys, key)
+	}
+
+	return auth, nil
+}
+
+// ParseGitCredential parses the key=value lines of the "git credential"
+// format. host and password are required; protocol defaults to https.
+func ParseGitCredential(content string) (GitCredential, error) {
+	credential := GitCredential{Protocol: "https"}
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return GitCredential{}, fmt.Errorf("expected key=value, got a line without =")
+		}
+
+		switch key {
+		case "protocol":
+			credential.Protocol = value
+		case "host":
+			credential.Host = value
+		case "path":
+			credential.Path = value
+		case "username":
+			credential.Username = value
+		case "password":
+			credential.Password = value
+		}
+	}
+
+	if credential.Host == "" {
+		return GitCredential{}, fmt.Errorf("no host")
+	}
+	if credential.Password == "" {
+		return GitCredential{}, fmt.Errorf("no password for %s", credential.Host)
+	}
+
+	return credential, nil
+}
+
+func (a GitAuth) IsEmpty() bool {
+	return len(a.Credentials) == 0 && len(a.Keys) == 0
+}
+
+// Bindings names the bindings the authentication came from.
+func (a GitAuth) Bindings() []string {
+	var names []string
+	for _, credential := range a.Credentials {
+		names = append(names, credential.Binding)
+	}
+	for _, key := range a.Keys {
+		names = append(names, key.Binding)
+	}
+	return names
+}
+
+// Summary describes the authentication for the build log, without secrets.
+func (a GitAuth) Summary() []string {
+	var lines []string
+	for _, credential := range a.Credentials {
+		lines = append(lines, fmt.Sprintf("Credentials for %s://%s", credential.Protocol, credential.Host))
+	}
+	for _, key := range a.Keys {
+		if strings.TrimSpace(key.KnownHosts) == "" {
+			lines = append(lines, fmt.Sprintf("SSH key from %s, trusting host keys on first use", key.Binding))
+		} else {
+			lines = append(lines, fmt.Sprintf("SSH key from %s", key.Binding))
+		}
+	}
+	return lines
+}
+
+// Write writes the credential store, SSH keys and known_hosts into dir, all
+// readable only by the current user, and returns the environment that makes
+// git use them. dir is expected to be temporary and removed after the build.
+func (a GitAuth) Write(dir string) ([]string, error) {
+	// fail instead of waiting for a password that nobody can type
+	env := []string{"GIT_TERMINAL_PROMPT=0"}
+
+	if len(a.Credentials) > 0 {
+		var store strings.Builder
+		for _, credential := range a.Credentials {
+			u := url.URL{
+				Scheme: credential.Protocol,
+				User:   url.UserPassword(credential.Username, credential.Password),
+				Host:   credential.Host,
+				Path:   credential.Path,
+			}
+			store.WriteString(u.String() + "\n")
+		}
+
+		path := filepath.Join(dir, "git-credentials")
+		err := os.WriteFile(path, []byte(store.String()), 0600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write git credentials: %w", err)
+		}
+
+		// GIT_CONFIG_* adds to the user's git configuration rather than
+		// replacing it
+		env = append(env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=credential.helper",
+			"GIT_CONFIG_VALUE_0=store --file="+shellQuote(path),
+		)
+	}
+
+	if len(a.Keys) > 0 {
+		sshDir := filepath.Join(dir, "ssh")
+		err := os.Mkdir(sshDir, 0700)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create ssh directory: %w", err)
+		}
+
+		command := []string{"ssh", "-F", "/dev/null", "-o", "IdentitiesOnly=yes"}
+
+		var knownHosts strings.Builder
+		for i, key := range a.Keys {
+			path := filepath.Join(sshDir, fmt.Sprintf("id_%d", i))
+
+			// OpenSSH rejects keys without a trailing newline
+			content := strings.TrimRight(key.PrivateKey, "\n") + "\n"
+			err = os.WriteFile(path, []byte(content), 0600)
+			if err != nil {
+				return nil, fmt.Errorf("failed to write ssh key from binding %s: %w", key.Binding, err)
+			}
+			command = append(command, "-i", shellQuote(path))
+
+			if hosts := strings.TrimSpace(key.KnownHosts); hosts != "" {
+				knownHosts.WriteString(hosts + "\n")
+			}
+		}
+
+		knownHostsPath := filepath.Join(sshDir, "known_hosts")
+		err = os.WriteFile(knownHostsPath, []byte(knownHosts.String()), 0600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write known_hosts: %w", err)
+		}
+
+		strict := "yes"
+		if knownHosts.Len() == 0 {
+			strict = "accept-new"
+		}
+		command = append(command, "-o", "UserKnownHostsFile="+shellQuote(knownHostsPath), "-o", "StrictHostKeyChecking="+strict)
+
+		env = append(env, "GIT_SSH_COMMAND="+strings.Join(command, " "))
+	}
+
+	return env, nil
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,203 @@
+package erlang
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// HexBindingType is the type of the service bindings that give the build
+// access to private Hex organisations, self-hosted Hex repositories and Hex
+// mirrors.
+//
+// A binding may hold:
+//   - api-key: a Hex API key; HEX_API_KEY for hex.pm, or the read key of the
+//     organisation or repository below
+//   - organization: a private hex.pm organisation
+//   - repo-url, public-key and optionally repo-name: a self-hosted repository;
+//     the name defaults to the name of the binding
+//   - mirror: a hex.pm mirror, used as HEX_MIRROR and HEX_CDN
+const HexBindingType = "hex"
+
+// HexRepo is a Hex repository that rebar3 fetches packages from in addition to
+// hex.pm. Key is a secret and must never be logged.
+type HexRepo struct {
+	Name      string
+	URL       string
+	PublicKey string
+	Key       string
+}
+
+// HexConfig is the Hex configuration read from hex bindings. APIKey and the
+// repository keys are secrets: they are only handed to rebar3 and make
+// through their environment and a temporary rebar3 global config.
+type HexConfig struct {
+	APIKey string
+	Mirror string
+	Repos  []HexRepo
+
+	// Bindings names the bindings the configuration came from.
+	Bindings []string
+}
+
+// LoadHexConfig reads every hex binding under platformDir.
+func LoadHexConfig(resolver BindingResolver, platformDir string) (HexConfig, error) {
+	bindings, err := resolver.Resolve(HexBindingType, "", platformDir)
+	if err != nil {
+		return HexConfig{}, fmt.Errorf("failed to resolve %s bindings: %w", HexBindingType, err)
+	}
+
+	var config HexConfig
+	for _, binding := range bindings {
+		values := map[string]string{}
+		for _, key := range []string{"api-key", "organization", "repo-name", "repo-url", "public-key", "mirror"} {
+			entry, ok := binding.Entries[key]
+			if !ok {
+				continue
+			}
+
+			value, err := entry.ReadString()
+			if err != nil {
+				return HexConfig{}, fmt.Errorf("failed to read %s from binding %s: %w", key, binding.Name, err)
+			}
+			values[key] = strings.TrimSpace(value)
+		}
+
+		switch {
+		case values["repo-url"] != "":
+			if values["public-key"] == "" {
+				return HexConfig{}, fmt.Errorf("binding %s sets repo-url but no public-key", binding.Name)
+			}
+
+			name := values["repo-name"]
+			if name == "" {
+				name = binding.Name
+			}
+
+			config.Repos = append(config.Repos, HexRepo{
+				Name:      name,
+				URL:       values["repo-url"],
+				PublicKey: values["public-key"],
+				Key:       values["api-key"],
+			})
+
+		case values["organization"] != "":
+			if values["api-key"] == "" {
+				return HexConfig{}, fmt.Errorf("binding %s sets organization but no api-key", binding.Name)
+			}
+
+			config.Repos = append(config.Repos, HexRepo{
+				Name: "hexpm:" + values["organization"],
+				Key:  values["api-key"],
+			})
+
+		case values["api-key"] != "":
+			if config.APIKey != "" {
+				return HexConfig{}, fmt.Errorf("binding %s sets a hex.pm api-key, but another hex binding already does", binding.Name)
+			}
+			config.APIKey = values["api-key"]
+		}
+
+		if values["mirror"] != "" {
+			if config.Mirror != "" && config.Mirror != values["mirror"] {
+				return HexConfig{}, fmt.Errorf("binding %s sets mirror %s, but another hex binding sets %s", binding.Name, values["mirror"], config.Mirror)
+			}
+			config.Mirror = values["mirror"]
+		}
+
+		config.Bindings = append(config.Bindings, binding.Name)
+	}
+
+	return config, nil
+}
+
+func (c HexConfig) IsEmpty() bool {
+	return len(c.Bindings) == 0
+}
+
+// Summary describes the configuration for the build log, without secrets.
+func (c HexConfig) Summary() []string {
+	var lines []string
+	if c.APIKey != "" {
+		lines = append(lines, "hex.pm API key: set")
+	}
+	for _, repo := range c.Repos {
+		if repo.URL != "" {
+			lines = append(lines, fmt.Sprintf("Repository %s: %s", repo.Name, repo.URL))
+		} else {
+			lines = append(lines, fmt.Sprintf("Organisation %s", strings.TrimPrefix(repo.Name, "hexpm:")))
+		}
+	}
+	if c.Mirror != "" {
+		lines = append(lines, fmt.Sprintf("Mirror: %s", c.Mirror))
+	}
+	return lines
+}
+
+// RebarConfig returns a rebar3 global config that adds the repositories to
+// the hex section.
+func (c HexConfig) RebarConfig() string {
+	var repos []string
+	for _, repo := range c.Repos {
+		fields := []string{fmt.Sprintf("name => %s", erlangBinary(repo.Name))}
+		if repo.URL != "" {
+			fields = append(fields,
+				fmt.Sprintf("repo_url => %s", erlangBinary(repo.URL)),
+				fmt.Sprintf("repo_public_key => %s", erlangBinary(repo.PublicKey)),
+			)
+		}
+		if repo.Key != "" {
+			fields = append(fields, fmt.Sprintf("repo_key => %s", erlangBinary(repo.Key)))
+		}
+		repos = append(repos, fmt.Sprintf("#{%s}", strings.Join(fields, ", ")))
+	}
+
+	return fmt.Sprintf("{hex, [{repos, [%s]}]}.\n", strings.Join(repos, ",\n  "))
+}
+
+// Env returns the environment variables that apply the configuration. When
+// there are repositories, the rebar3 global config is expected in
+// globalConfigDir, as written by WriteRebarConfig.
+func (c HexConfig) Env(globalConfigDir string) []string {
+	var env []string
+	if c.APIKey != "" {
+		env = append(env, "HEX_API_KEY="+c.APIKey)
+	}
+	if c.Mirror != "" {
+		env = append(env, "HEX_MIRROR="+c.Mirror, "HEX_CDN="+c.Mirror)
+	}
+	if len(c.Repos) > 0 {
+		env = append(env, "REBAR_GLOBAL_CONFIG_DIR="+globalConfigDir)
+	}
+	return env
+}
+
+// WriteRebarConfig writes the rebar3 global config for REBAR_GLOBAL_CONFIG_DIR
+// dir, readable only by the current user.
+func (c HexConfig) WriteRebarConfig(dir string) error {
+	path := RebarGlobalConfigPath(dir)
+
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return fmt.Errorf("failed to create rebar3 global config directory: %w", err)
+	}
+
+	err = os.WriteFile(path, []byte(c.RebarConfig()), 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write rebar3 hex configuration: %w", err)
+	}
+	return nil
+}
+
+// RebarGlobalConfigPath returns where rebar3 reads its global config when
+// REBAR_GLOBAL_CONFIG_DIR is dir. rebar3 treats the variable as a home
+// directory (rebar_dir:home_dir/0) and reads <home>/.config/rebar3/rebar.config
+// (rebar_dir:global_config/1).
+func RebarGlobalConfigPath(dir string) string {
+	return filepath.Join(dir, ".config", "rebar3", "rebar.config")
+}
+
+func erlangBinary(value string) string {
+	return `<<"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `">>`
+}
@@ -0,0 +1,166 @@
+package erlang_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SnakeDoc/erlang-cnb"
+	"github.com/SnakeDoc/erlang-cnb/fakes"
+	"github.com/paketo-buildpacks/packit/v2/servicebindings"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testHex(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		resolver *fakes.BindingResolver
+	)
+
+	binding := func(name string, entries map[string]string) servicebindings.Binding {
+		b := servicebindings.Binding{Name: name, Type: "hex", Entries: map[string]*servicebindings.Entry{}}
+		for key, value := range entries {
+			b.Entries[key] = servicebindings.NewWithValue([]byte(value))
+		}
+		return b
+	}
+
+	it.Before(func() {
+		resolver = &fakes.BindingResolver{}
+	})
+
+	context("LoadHexConfig", func() {
+		it("reads organisations, repositories, the hex.pm key and the mirror", func() {
+			resolver.ResolveCall.Returns.BindingSlice = []servicebindings.Binding{
+				binding("hexpm", map[string]string{"api-key": "hexpm-key\n", "mirror": "https://hex.example.com"}),
+				binding("acme", map[string]string{"organization": "acme", "api-key": "acme-key"}),
+				binding("internal", map[string]string{"repo-url": "https://repo.example.com", "public-key": "-----BEGIN PUBLIC KEY-----\nabc\n-----END PUBLIC KEY-----\n", "api-key": "repo-key"}),
+				binding("other", map[string]string{"repo-name": "mirror2", "repo-url": "https://other.example.com", "public-key": "key"}),
+			}
+
+			config, err := erlang.LoadHexConfig(resolver, "some-platform")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(resolver.ResolveCall.Receives.Typ).To(Equal("hex"))
+			Expect(resolver.ResolveCall.Receives.PlatformDir).To(Equal("some-platform"))
+
+			Expect(config).To(Equal(erlang.HexConfig{
+				APIKey: "hexpm-key",
+				Mirror: "https://hex.example.com",
+				Repos: []erlang.HexRepo{
+					{Name: "hexpm:acme", Key: "acme-key"},
+					{Name: "internal", URL: "https://repo.example.com", PublicKey: "-----BEGIN PUBLIC KEY-----\nabc\n-----END PUBLIC KEY-----", Key: "repo-key"},
+					{Name: "mirror2", URL: "https://other.example.com", PublicKey: "key"},
+				},
+				Bindings: []string{"hexpm", "acme", "internal", "other"},
+			}))
+		})
+
+		it("is empty without bindings", func() {
+			config, err := erlang.LoadHexConfig(resolver, "some-platform")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.IsEmpty()).To(BeTrue())
+		})
+
+		context("failure cases", func() {
+			it("requires a public key for a repository", func() {
+				resolver.ResolveCall.Returns.BindingSlice = []servicebindings.Binding{
+					binding("internal", map[string]string{"repo-url": "https://repo.example.com", "api-key": "secret"}),
+				}
+
+				_, err := erlang.LoadHexConfig(resolver, "some-platform")
+				Expect(err).To(MatchError("binding internal sets repo-url but no public-key"))
+			})
+
+			it("requires an api key for an organisation", func() {
+				resolver.ResolveCall.Returns.BindingSlice = []servicebindings.Binding{
+					binding("acme", map[string]string{"organization": "acme"}),
+				}
+
+				_, err := erlang.LoadHexConfig(resolver, "some-platform")
+				Expect(err).To(MatchError("binding acme sets organization but no api-key"))
+			})
+
+			it("rejects two hex.pm keys without revealing them", func() {
+				resolver.ResolveCall.Returns.BindingSlice = []servicebindings.Binding{
+					binding("one", map[string]string{"api-key": "first-secret"}),
+					binding("two", map[string]string{"api-key": "second-secret"}),
+				}
+
+				_, err := erlang.LoadHexConfig(resolver, "some-platform")
+				Expect(err).To(MatchError("binding two sets a hex.pm api-key, but another hex binding already does"))
+			})
+
+			it("wraps resolver errors", func() {
+				resolver.ResolveCall.Returns.Error = errors.New("bad bindings")
+
+				_, err := erlang.LoadHexConfig(resolver, "some-platform")
+				Expect(err).To(MatchError("failed to resolve hex bindings: bad bindings"))
+			})
+		})
+	})
+
+	context("HexConfig", func() {
+		var config erlang.HexConfig
+
+		it.Before(func() {
+			config = erlang.HexConfig{
+				APIKey: "hexpm-key",
+				Mirror: "https://hex.example.com",
+				Repos: []erlang.HexRepo{
+					{Name: "hexpm:acme", Key: "acme-key"},
+					{Name: "internal", URL: "https://repo.example.com", PublicKey: `a "quoted" key`},
+				},
+				Bindings: []string{"hexpm", "acme", "internal"},
+			}
+		})
+
+		it("summarises without secrets", func() {
+			Expect(config.Summary()).To(Equal([]string{
+				"hex.pm API key: set",
+				"Organisation acme",
+				"Repository internal: https://repo.example.com",
+				"Mirror: https://hex.example.com",
+			}))
+		})
+
+		it("renders the rebar3 repos", func() {
+			Expect(config.RebarConfig()).To(Equal(`{hex, [{repos, [#{name => <<"hexpm:acme">>, repo_key => <<"acme-key">>},
+  #{name => <<"internal">>, repo_url => <<"https://repo.example.com">>, repo_public_key => <<"a \"quoted\" key">>}]}]}.
+`))
+
+			terms, err := erlang.ParseTerms(config.RebarConfig())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(terms).To(HaveLen(1))
+		})
+
+		it("returns the environment", func() {
+			Expect(config.Env("/tmp/rebar3-hex")).To(Equal([]string{
+				"HEX_API_KEY=hexpm-key",
+				"HEX_MIRROR=https://hex.example.com",
+				"HEX_CDN=https://hex.example.com",
+				"REBAR_GLOBAL_CONFIG_DIR=/tmp/rebar3-hex",
+			}))
+		})
+
+		it("writes the rebar3 config readable only by the user", func() {
+			dir := t.TempDir()
+			Expect(config.WriteRebarConfig(dir)).To(Succeed())
+
+			path := erlang.RebarGlobalConfigPath(dir)
+			Expect(path).To(Equal(filepath.Join(dir, ".config", "rebar3", "rebar.config")))
+
+			info, err := os.Stat(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+			content, err := os.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal(config.RebarConfig()))
+		})
+	})
+}
@@ -14,5 +14,27 @@ func TestUnitErlang(t *testing.T) {
 	suite("ErlangVersionResolver", testErlangVersionResolver)
 	suite("ToolVersionsParser", testToolVersionsParser)
 	suite("ErlangInstaller", testErlangInstaller)
+	suite("LayerManifest", testLayerManifest)
+	suite("ErlangTerms", testErlangTerms)
+	suite("AppPruner", testAppPruner)
+	suite("BeamStripper", testBeamStripper)
+	suite("SBOM", testSBOM)
+	suite("RebarLock", testRebarLock)
+	suite("MixLock", testMixLock)
+	suite("Advisories", testAdvisories)
+	suite("Policy", testPolicy)
+	suite("Support", testSupport)
+	suite("Provenance", testProvenance)
+	suite("Labels", testLabels)
+	suite("Reproducible", testReproducible)
+	suite("Rebar3", testRebar3)
+	suite("Release", testRelease)
+	suite("ErlangMk", testErlangMk)
+	suite("PlainProject", testPlainProject)
+	suite("DepsCache", testDepsCache)
+	suite("Hex", testHex)
+	suite("GitCredentials", testGitCredentials)
+	suite("Dialyzer", testDialyzer)
+	suite("TestTasks", testTestTasks)
 	suite.Run(t)
 }
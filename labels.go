@@ -0,0 +1,56 @@
+package erlang
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	OTPVersionLabel  = "io.buildpacks.erlang.otp-version"
+	ERTSVersionLabel = "io.buildpacks.erlang.erts-version"
+	ArchLabel        = "io.buildpacks.erlang.arch"
+	SourceLabel      = "io.buildpacks.erlang.source"
+)
+
+// ReadERTSVersion returns the version of the runtime system installed under
+// layerPath, taken from its erts-<vsn> directory. When there are several the
+// newest wins; when there is none an empty string is returned.
+func ReadERTSVersion(layerPath string) (string, error) {
+	dirs, err := filepath.Glob(filepath.Join(layerPath, "erts-*"))
+	if err != nil {
+		return "", err
+	}
+
+	var newest []int
+	var newestText string
+	for _, dir := range dirs {
+		version := strings.TrimPrefix(filepath.Base(dir), "erts-")
+
+		ver, err := parseVersion(version)
+		if err != nil {
+			return "", fmt.Errorf("unexpected ERTS directory %s", dir)
+		}
+
+		if newest == nil || compareVersions(ver, newest) > 0 {
+			newest, newestText = ver, version
+		}
+	}
+
+	return newestText, nil
+}
+
+// ImageLabels returns the labels describing the runtime in the image.
+func ImageLabels(otpVersion, ertsVersion, arch, source string) map[string]string {
+	labels := map[string]string{
+		OTPVersionLabel: otpVersion,
+		ArchLabel:       arch,
+		SourceLabel:     source,
+	}
+
+	if ertsVersion != "" {
+		labels[ERTSVersionLabel] = ertsVersion
+	}
+
+	return labels
+}
@@ -0,0 +1,70 @@
+package erlang_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SnakeDoc/erlang-cnb"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testLabels(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerPath string
+	)
+
+	it.Before(func() {
+		layerPath = t.TempDir()
+	})
+
+	context("ReadERTSVersion", func() {
+		it("reads the version from the erts directory", func() {
+			Expect(os.Mkdir(filepath.Join(layerPath, "erts-15.2.7"), os.ModePerm)).To(Succeed())
+
+			version, err := erlang.ReadERTSVersion(layerPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(Equal("15.2.7"))
+		})
+
+		it("picks the newest of several erts directories", func() {
+			for _, dir := range []string{"erts-15.2.10", "erts-15.2.9", "erts-15.2"} {
+				Expect(os.Mkdir(filepath.Join(layerPath, dir), os.ModePerm)).To(Succeed())
+			}
+
+			version, err := erlang.ReadERTSVersion(layerPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(Equal("15.2.10"))
+		})
+
+		it("returns an empty version when there is no erts directory", func() {
+			version, err := erlang.ReadERTSVersion(layerPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(BeEmpty())
+		})
+
+		context("when an erts directory has no version", func() {
+			it("returns an error", func() {
+				Expect(os.Mkdir(filepath.Join(layerPath, "erts-dev"), os.ModePerm)).To(Succeed())
+
+				_, err := erlang.ReadERTSVersion(layerPath)
+				Expect(err).To(MatchError(ContainSubstring("unexpected ERTS directory")))
+			})
+		})
+	})
+
+	context("ImageLabels", func() {
+		it("omits the ERTS version when it is unknown", func() {
+			labels := erlang.ImageLabels("28.1.1", "", "arm64", "https://example.com/otp.tar.gz")
+			Expect(labels).To(Equal(map[string]string{
+				erlang.OTPVersionLabel: "28.1.1",
+				erlang.ArchLabel:       "arm64",
+				erlang.SourceLabel:     "https://example.com/otp.tar.gz",
+			}))
+		})
+	})
+}
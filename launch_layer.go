@@ -0,0 +1,138 @@
+package erlang
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// launchPrunedDirs are the directories that only matter when compiling
+// against OTP. They are dropped from the launch layer when they appear at the
+// root of the installation, in erts-*, in usr or in lib/<app>.
+var launchPrunedDirs = map[string]bool{
+	"include": true,
+	"src":     true,
+	"doc":     true,
+	"man":     true,
+	"misc":    true,
+}
+
+// LaunchLayerStats describes the result of assembling the launch layer.
+type LaunchLayerStats struct {
+	CopiedBytes int64
+	PrunedBytes int64
+}
+
+// AssembleLaunchLayer copies the Erlang installation at buildPath into
+// launchPath, leaving out headers, sources, documentation and static
+// libraries. Scripts that embed buildPath are rewritten to point at
+// launchPath.
+func AssembleLaunchLayer(buildPath, launchPath string) (LaunchLayerStats, error) {
+	var stats LaunchLayerStats
+
+	err := filepath.WalkDir(buildPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(buildPath, path)
+		if err != nil {
+			return err
+		}
+
+		if isPrunedForLaunch(rel, d) {
+			size, err := treeSize(path)
+			if err != nil {
+				return err
+			}
+			stats.PrunedBytes += size
+
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		target := filepath.Join(launchPath, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+
+		case info.Mode().IsRegular():
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+
+			if !bytes.Contains(content, []byte{0}) {
+				content = bytes.ReplaceAll(content, []byte(buildPath), []byte(launchPath))
+			}
+
+			stats.CopiedBytes += int64(len(content))
+			return os.WriteFile(target, content, info.Mode().Perm())
+		}
+
+		return nil
+	})
+	if err != nil {
+		return LaunchLayerStats{}, fmt.Errorf("failed to assemble launch layer from %s: %w", buildPath, err)
+	}
+
+	return stats, nil
+}
+
+func isPrunedForLaunch(rel string, d fs.DirEntry) bool {
+	if !d.IsDir() {
+		return strings.HasSuffix(d.Name(), ".a")
+	}
+
+	if !launchPrunedDirs[d.Name()] {
+		return false
+	}
+
+	parent := filepath.ToSlash(filepath.Dir(rel))
+	switch {
+	case parent == ".", parent == "usr":
+		return true
+	case strings.HasPrefix(parent, "erts-") && !strings.Contains(parent, "/"):
+		return true
+	case strings.HasPrefix(parent, "lib/") && strings.Count(parent, "/") == 1:
+		return true
+	}
+
+	return false
+}
+
+func treeSize(path string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
@@ -0,0 +1,136 @@
+package erlang
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	FileCountKey = "file-count"
+	ChecksumKey  = "checksum"
+)
+
+// keyBinaries are the files, relative to the layer root, whose contents are
+// hashed into the manifest. Glob patterns are allowed.
+var keyBinaries = []string{
+	"bin/erl",
+	"bin/erlc",
+	"bin/escript",
+	"erts-*/bin/beam.smp",
+	"erts-*/bin/erlexec",
+}
+
+// packitOwned are the directories at the layer root that packit writes after
+// the build returns, so they are never part of the installed runtime.
+var packitOwned = map[string]bool{
+	"env":        true,
+	"env.build":  true,
+	"env.launch": true,
+}
+
+// LayerManifest summarises an installed layer so that a restored cache can be
+// checked for missing or damaged files before it is reused.
+type LayerManifest struct {
+	FileCount int
+	Checksum  string
+}
+
+func ComputeLayerManifest(layerPath string) (LayerManifest, error) {
+	var manifest LayerManifest
+
+	err := filepath.WalkDir(layerPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && filepath.Dir(path) == filepath.Clean(layerPath) && packitOwned[d.Name()] {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() {
+			manifest.FileCount++
+		}
+		return nil
+	})
+	if err != nil {
+		return LayerManifest{}, fmt.Errorf("failed to walk %s: %w", layerPath, err)
+	}
+
+	var files []string
+	for _, pattern := range keyBinaries {
+		matches, err := filepath.Glob(filepath.Join(layerPath, pattern))
+		if err != nil {
+			return LayerManifest{}, err
+		}
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+
+	hash := sha256.New()
+	for _, file := range files {
+		rel, err := filepath.Rel(layerPath, file)
+		if err != nil {
+			return LayerManifest{}, err
+		}
+
+		f, err := os.Open(file)
+		if err != nil {
+			return LayerManifest{}, fmt.Errorf("failed to hash %s: %w", rel, err)
+		}
+
+		fmt.Fprintf(hash, "%s\x00", filepath.ToSlash(rel))
+		_, err = io.Copy(hash, f)
+		f.Close()
+		if err != nil {
+			return LayerManifest{}, fmt.Errorf("failed to hash %s: %w", rel, err)
+		}
+	}
+
+	manifest.Checksum = hex.EncodeToString(hash.Sum(nil))
+
+	return manifest, nil
+}
+
+// ManifestFromMetadata reads a manifest previously stored in layer metadata.
+// The boolean result is false when the metadata does not hold a manifest.
+func ManifestFromMetadata(metadata map[string]any) (LayerManifest, bool) {
+	checksum, ok := metadata[ChecksumKey].(string)
+	if !ok {
+		return LayerManifest{}, false
+	}
+
+	var count int
+	switch v := metadata[FileCountKey].(type) {
+	case int:
+		count = v
+	case int64:
+		count = int(v)
+	default:
+		return LayerManifest{}, false
+	}
+
+	return LayerManifest{FileCount: count, Checksum: checksum}, true
+}
+
+// Verify compares the manifest against the current contents of layerPath and
+// returns a description of the first difference found.
+func (m LayerManifest) Verify(layerPath string) error {
+	actual, err := ComputeLayerManifest(layerPath)
+	if err != nil {
+		return err
+	}
+
+	if actual.FileCount != m.FileCount {
+		return fmt.Errorf("expected %d files, found %d", m.FileCount, actual.FileCount)
+	}
+
+	if actual.Checksum != m.Checksum {
+		return fmt.Errorf("checksum of key binaries does not match")
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package erlang_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SnakeDoc/erlang-cnb"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testLayerManifest(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerPath string
+	)
+
+	it.Before(func() {
+		var err error
+		layerPath, err = os.MkdirTemp("", "layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.MkdirAll(filepath.Join(layerPath, "bin"), os.ModePerm)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(layerPath, "erts-16.1", "bin"), os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(layerPath, "bin", "erl"), []byte("erl"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(layerPath, "erts-16.1", "bin", "beam.smp"), []byte("beam"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(layerPath, "README"), []byte("readme"), 0644)).To(Succeed())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layerPath)).To(Succeed())
+	})
+
+	it("counts files and hashes the key binaries", func() {
+		manifest, err := erlang.ComputeLayerManifest(layerPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manifest.FileCount).To(Equal(3))
+		Expect(manifest.Checksum).To(HaveLen(64))
+
+		Expect(manifest.Verify(layerPath)).To(Succeed())
+	})
+
+	it("ignores changes to files that are not key binaries", func() {
+		manifest, err := erlang.ComputeLayerManifest(layerPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.WriteFile(filepath.Join(layerPath, "README"), []byte("changed"), 0644)).To(Succeed())
+		Expect(manifest.Verify(layerPath)).To(Succeed())
+	})
+
+	it("detects a changed key binary", func() {
+		manifest, err := erlang.ComputeLayerManifest(layerPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.WriteFile(filepath.Join(layerPath, "erts-16.1", "bin", "beam.smp"), []byte("corrupt"), 0755)).To(Succeed())
+		Expect(manifest.Verify(layerPath)).To(MatchError("checksum of key binaries does not match"))
+	})
+
+	it("detects a missing file", func() {
+		manifest, err := erlang.ComputeLayerManifest(layerPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.Remove(filepath.Join(layerPath, "README"))).To(Succeed())
+		Expect(manifest.Verify(layerPath)).To(MatchError("expected 3 files, found 2"))
+	})
+
+	it("ignores the environment packit writes into the layer", func() {
+		manifest, err := erlang.ComputeLayerManifest(layerPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		for _, dir := range []string{"env", "env.build", "env.launch"} {
+			Expect(os.MkdirAll(filepath.Join(layerPath, dir), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(layerPath, dir, "PATH.prepend"), []byte("bin"), 0644)).To(Succeed())
+		}
+		Expect(manifest.Verify(layerPath)).To(Succeed())
+	})
+
+	context("ManifestFromMetadata", func() {
+		it("reads a manifest stored as TOML integers", func() {
+			manifest, ok := erlang.ManifestFromMetadata(map[string]any{
+				"file-count": int64(3),
+				"checksum":   "abc",
+			})
+			Expect(ok).To(BeTrue())
+			Expect(manifest).To(Equal(erlang.LayerManifest{FileCount: 3, Checksum: "abc"}))
+		})
+
+		it("reports a missing manifest", func() {
+			_, ok := erlang.ManifestFromMetadata(map[string]any{"version": "28.1.1"})
+			Expect(ok).To(BeFalse())
+		})
+	})
+}
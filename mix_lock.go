@@ -0,0 +1,336 @@
+package erlang
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseMixLock reads the dependencies pinned in a mix.lock file. A missing
+// file yields no dependencies. Dependencies are returned sorted by name.
+func ParseMixLock(path string) ([]LockedDependency, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	p := &elixirParser{src: string(content)}
+	term, err := p.term()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	p.skipSpace()
+	if !p.eof() {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, p.errorf("unexpected text after lock map"))
+	}
+
+	entries, ok := term.(Map)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse %s: expected a map", path)
+	}
+
+	var deps []LockedDependency
+	for _, entry := range entries {
+		name := termString(entry.Key)
+
+		lock, ok := entry.Value.(Tuple)
+		if !ok || len(lock) < 2 {
+			return nil, fmt.Errorf("failed to parse %s: unexpected entry for %s", path, name)
+		}
+
+		dep := LockedDependency{Name: name}
+		switch lock[0] {
+		case Atom("hex"):
+			// {:hex, name, version, inner_hash, managers, deps, repo, outer_hash}
+			if len(lock) < 4 {
+				return nil, fmt.Errorf("failed to parse %s: incomplete hex entry for %s", path, name)
+			}
+			dep.Source = "hex"
+			dep.Package = termString(lock[1])
+			dep.Version = termString(lock[2])
+			dep.InnerSHA256 = strings.ToLower(termString(lock[3]))
+			if len(lock) >= 8 {
+				dep.SHA256 = strings.ToLower(termString(lock[7]))
+			}
+
+		case Atom("git"):
+			// {:git, url, sha, opts}
+			if len(lock) < 3 {
+				return nil, fmt.Errorf("failed to parse %s: incomplete git entry for %s", path, name)
+			}
+			dep.Source = "git"
+			dep.URL = termString(lock[1])
+			dep.Ref = termString(lock[2])
+			dep.Version = dep.Ref
+
+		case Atom("path"):
+			dep.Source = "path"
+			dep.URL = termString(lock[1])
+
+		default:
+			return nil, fmt.Errorf("failed to parse %s: dependency %s has unsupported source %v", path, name, lock[0])
+		}
+
+		deps = append(deps, dep)
+	}
+
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Name < deps[j].Name })
+
+	return deps, nil
+}
+
+// elixirParser reads the subset of Elixir literal syntax used by mix.lock:
+// maps, tuples, lists, keyword lists, atoms, strings and numbers. Strings are
+// returned as Binary and keyword pairs as {Atom, Value} tuples, mirroring
+// their Erlang representation.
+type elixirParser struct {
+	src string
+	pos int
+}
+
+func (p *elixirParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *elixirParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *elixirParser) errorf(format string, args ...any) error {
+	line := strings.Count(p.src[:min(p.pos, len(p.src))], "\n") + 1
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func (p *elixirParser) skipSpace() {
+	for !p.eof() {
+		c := p.peek()
+		switch {
+		case c == '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+		case c == ' ', c == '\t', c == '\n', c == '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *elixirParser) term() (any, error) {
+	p.skipSpace()
+	if p.eof() {
+		return nil, p.errorf("unexpected end of input")
+	}
+
+	c := p.peek()
+	switch {
+	case strings.HasPrefix(p.src[p.pos:], "%{"):
+		p.pos += 2
+		return p.mapEntries()
+
+	case c == '{':
+		p.pos++
+		items, err := p.sequence('}')
+		return Tuple(items), err
+
+	case c == '[':
+		p.pos++
+		items, err := p.sequence(']')
+		return List(items), err
+
+	case c == '"':
+		s, err := p.quoted()
+		return Binary(s), err
+
+	case c == ':':
+		p.pos++
+		if p.peek() == '"' {
+			s, err := p.quoted()
+			return Atom(s), err
+		}
+		return Atom(p.identifier()), nil
+
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := p.pos
+		p.pos++
+		for !p.eof() && (p.peek() == '.' || p.peek() == '_' || (p.peek() >= '0' && p.peek() <= '9')) {
+			p.pos++
+		}
+		text := strings.ReplaceAll(p.src[start:p.pos], "_", "")
+		if strings.Contains(text, ".") {
+			return strconv.ParseFloat(text, 64)
+		}
+		return strconv.ParseInt(text, 10, 64)
+
+	case c >= 'a' && c <= 'z':
+		// bare true, false and nil
+		return Atom(p.identifier()), nil
+	}
+
+	return nil, p.errorf("unexpected character %q", c)
+}
+
+// keywordKey reads a "key:" or "\"key\":" prefix, reporting false and leaving
+// the position unchanged when there is none.
+func (p *elixirParser) keywordKey() (Atom, bool, error) {
+	start := p.pos
+
+	var key string
+	if p.peek() == '"' {
+		s, err := p.quoted()
+		if err != nil {
+			return "", false, err
+		}
+		key = s
+	} else if c := p.peek(); c >= 'a' && c <= 'z' || c == '_' {
+		key = p.identifier()
+	} else {
+		return "", false, nil
+	}
+
+	if p.peek() == ':' && p.pos+1 < len(p.src) && p.src[p.pos+1] != ':' {
+		p.pos++
+		return Atom(key), true, nil
+	}
+
+	p.pos = start
+	return "", false, nil
+}
+
+func (p *elixirParser) sequence(end byte) ([]any, error) {
+	items := []any{}
+
+	for {
+		p.skipSpace()
+		if p.peek() == end {
+			p.pos++
+			return items, nil
+		}
+
+		key, isKeyword, err := p.keywordKey()
+		if err != nil {
+			return nil, err
+		}
+
+		item, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+
+		if isKeyword {
+			item = Tuple{key, item}
+		}
+		items = append(items, item)
+
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case end:
+		default:
+			return nil, p.errorf("expected ',' or '%c'", end)
+		}
+	}
+}
+
+func (p *elixirParser) mapEntries() (Map, error) {
+	entries := Map{}
+
+	for {
+		p.skipSpace()
+		if p.peek() == '}' {
+			p.pos++
+			return entries, nil
+		}
+
+		var key any
+		atom, isKeyword, err := p.keywordKey()
+		if err != nil {
+			return nil, err
+		}
+
+		if isKeyword {
+			key = atom
+		} else {
+			key, err = p.term()
+			if err != nil {
+				return nil, err
+			}
+
+			p.skipSpace()
+			if !strings.HasPrefix(p.src[p.pos:], "=>") {
+				return nil, p.errorf("expected '=>' in map")
+			}
+			p.pos += 2
+		}
+
+		value, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, MapEntry{Key: key, Value: value})
+
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+		default:
+			return nil, p.errorf("expected ',' or '}' in map")
+		}
+	}
+}
+
+func (p *elixirParser) identifier() string {
+	start := p.pos
+	for !p.eof() && (isAtomChar(p.peek()) || p.peek() == '?' || p.peek() == '!') {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *elixirParser) quoted() (string, error) {
+	p.pos++
+
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated string")
+		}
+
+		c := p.peek()
+		p.pos++
+		switch c {
+		case '"':
+			return b.String(), nil
+		case '\\':
+			if p.eof() {
+				return "", p.errorf("unterminated string")
+			}
+			escaped := p.peek()
+			p.pos++
+			switch escaped {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			default:
+				b.WriteByte(escaped)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package erlang_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SnakeDoc/erlang-cnb"
+	"github.com/paketo-buildpacks/packit/v2/sbom"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testMixLock(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		workingDir string
+		path       string
+	)
+
+	it.Before(func() {
+		var err error
+		workingDir, err = os.MkdirTemp("", "working-dir")
+		Expect(err).NotTo(HaveOccurred())
+
+		path = filepath.Join(workingDir, "mix.lock")
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	it("parses hex, git and path dependencies", func() {
+		Expect(os.WriteFile(path, []byte(`%{
+  "cowboy": {:hex, :cowboy, "2.10.0", "FF9FFEFF91DAE4AE270DD975642997AFE2A1179D94B1887863E43F681A203E26", [:make, :rebar3], [{:cowlib, "2.12.1", [hex: :cowlib, repo: "hexpm", optional: false]}, {:ranch, "1.8.0", [hex: :ranch, repo: "hexpm", optional: false]}], "hexpm", "3AFDCCB7183CC6F143CB14D3CF51FA00E53DB9EC80CDCD525482F5E99BC41D6B"},
+  "mylib": {:git, "https://github.com/acme/mylib.git", "4a0b6e3f2c1d9e8b7a6f5e4d3c2b1a0f9e8d7c6b", [tag: "v1.0.0"]},
+  # a path dependency
+  "local_lib": {:path, "../local_lib"},
+  "old": {:hex, :old, "0.1.0", "abcdef", [:mix], [], "hexpm"},
+}
+`), 0644)).To(Succeed())
+
+		deps, err := erlang.ParseMixLock(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deps).To(Equal([]erlang.LockedDependency{
+			{
+				Name:        "cowboy",
+				Version:     "2.10.0",
+				Package:     "cowboy",
+				Source:      "hex",
+				SHA256:      "3afdccb7183cc6f143cb14d3cf51fa00e53db9ec80cdcd525482f5e99bc41d6b",
+				InnerSHA256: "ff9ffeff91dae4ae270dd975642997afe2a1179d94b1887863e43f681a203e26",
+			},
+			{
+				Name:   "local_lib",
+				Source: "path",
+				URL:    "../local_lib",
+			},
+			{
+				Name:    "mylib",
+				Version: "4a0b6e3f2c1d9e8b7a6f5e4d3c2b1a0f9e8d7c6b",
+				Source:  "git",
+				URL:     "https://github.com/acme/mylib.git",
+				Ref:     "4a0b6e3f2c1d9e8b7a6f5e4d3c2b1a0f9e8d7c6b",
+			},
+			{
+				Name:        "old",
+				Version:     "0.1.0",
+				Package:     "old",
+				Source:      "hex",
+				InnerSHA256: "abcdef",
+			},
+		}))
+	})
+
+	it("reads the package name of aliased dependencies", func() {
+		Expect(os.WriteFile(path, []byte(`%{
+  "json": {:hex, :jason, "1.4.1", "AF1504E35F629DDCDD6ADDB3513C3853991F694921B1B9368B0BD32BEB9F1B63", [:mix], [], "hexpm", "FBB01ECDFD565B56261302F7E1FCC27C4FB8F32D56EAB74DB621FC154604A7A1"},
+}
+`), 0644)).To(Succeed())
+
+		deps, err := erlang.ParseMixLock(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deps).To(Equal([]erlang.LockedDependency{
+			{
+				Name:        "json",
+				Version:     "1.4.1",
+				Package:     "jason",
+				Source:      "hex",
+				SHA256:      "fbb01ecdfd565b56261302f7e1fcc27c4fb8f32d56eab74db621fc154604a7a1",
+				InnerSHA256: "af1504e35f629ddcdd6addb3513c3853991f694921b1b9368b0bd32beb9f1b63",
+			},
+		}))
+
+		bom, err := erlang.GenerateDependencySBOM(path, deps)
+		Expect(err).NotTo(HaveOccurred())
+
+		formatter, err := bom.InFormats(sbom.SyftFormat)
+		Expect(err).NotTo(HaveOccurred())
+
+		content, err := io.ReadAll(formatter.Formats()[0].Content)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(content)).To(ContainSubstring(`"purl":"pkg:hex/jason@1.4.1"`))
+	})
+
+	it("returns nothing when the lock file does not exist", func() {
+		deps, err := erlang.ParseMixLock(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deps).To(BeEmpty())
+	})
+
+	context("failure cases", func() {
+		it("reports malformed lock files", func() {
+			Expect(os.WriteFile(path, []byte(`%{"cowboy": {:hex, :cowboy`), 0644)).To(Succeed())
+
+			_, err := erlang.ParseMixLock(path)
+			Expect(err).To(MatchError(ContainSubstring("failed to parse")))
+		})
+
+		it("reports unsupported dependency sources", func() {
+			Expect(os.WriteFile(path, []byte(`%{"x": {:svn, "url"}}`), 0644)).To(Succeed())
+
+			_, err := erlang.ParseMixLock(path)
+			Expect(err).To(MatchError(ContainSubstring("dependency x has unsupported source svn")))
+		})
+	})
+}
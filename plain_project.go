@@ -0,0 +1,130 @@
+package erlang
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	Emakefile = "Emakefile"
+
+	// DefaultStartFunction is called in BP_ERLANG_START_MODULE when
+	// BP_ERLANG_START_FUNCTION is not set, as with "erl -s Module".
+	DefaultStartFunction = "start"
+)
+
+// PlainProject is an Erlang project built without rebar3 or erlang.mk: either
+// an Emakefile for "erl -make", or bare sources under src/ for erlc.
+type PlainProject struct {
+	// Emakefile is the path of the Emakefile, if the project has one.
+	Emakefile string
+
+	// Sources are the src/*.erl files, relative to the working directory,
+	// compiled with erlc when there is no Emakefile.
+	Sources []string
+}
+
+// FindPlainProject looks for an Emakefile or src/*.erl in workingDir. A Mix
+// project may carry Erlang sources in src/ too, but compiles them itself, so
+// it reports false when there is a mix.exs.
+func FindPlainProject(workingDir string) (PlainProject, bool, error) {
+	_, err := os.Stat(filepath.Join(workingDir, "mix.exs"))
+	if err == nil {
+		return PlainProject{}, false, nil
+	}
+	if !os.IsNotExist(err) {
+		return PlainProject{}, false, fmt.Errorf("failed to stat mix.exs: %w", err)
+	}
+
+	emakefile := filepath.Join(workingDir, Emakefile)
+	_, err = os.Stat(emakefile)
+	if err == nil {
+		return PlainProject{Emakefile: emakefile}, true, nil
+	}
+	if !os.IsNotExist(err) {
+		return PlainProject{}, false, fmt.Errorf("failed to stat %s: %w", Emakefile, err)
+	}
+
+	sources, err := filepath.Glob(filepath.Join(workingDir, "src", "*.erl"))
+	if err != nil {
+		return PlainProject{}, false, err
+	}
+	if len(sources) == 0 {
+		return PlainProject{}, false, nil
+	}
+
+	for i, source := range sources {
+		sources[i], err = filepath.Rel(workingDir, source)
+		if err != nil {
+			return PlainProject{}, false, err
+		}
+	}
+	sort.Strings(sources)
+
+	return PlainProject{Sources: sources}, true, nil
+}
+
+// CompileArgs returns the arguments for "erl" when there is an Emakefile, or
+// for "erlc" otherwise. erlc writes to ebin/ and searches include/.
+func (p PlainProject) CompileArgs() []string {
+	if p.Emakefile != "" {
+		return []string{"-make"}
+	}
+
+	args := []string{"-o", "ebin", "-I", "include"}
+	return append(args, p.Sources...)
+}
+
+// CodePaths returns the directories, relative to the working directory, that
+// hold the compiled modules: the outdir of every Emakefile entry, or ebin/.
+func (p PlainProject) CodePaths() ([]string, error) {
+	if p.Emakefile == "" {
+		return []string{"ebin"}, nil
+	}
+
+	content, err := os.ReadFile(p.Emakefile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", Emakefile, err)
+	}
+
+	terms, err := ParseTerms(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", Emakefile, err)
+	}
+
+	var paths []string
+	seen := map[string]bool{}
+	for _, term := range terms {
+		// each entry is Modules or {Modules, Options}; modules without an
+		// outdir are written to the current directory
+		path := "."
+		if entry, ok := term.(Tuple); ok && len(entry) == 2 {
+			if outdir, ok := proplistValue(asList(entry[1]), "outdir"); ok && termString(outdir) != "" {
+				path = filepath.Clean(termString(outdir))
+			}
+		}
+
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	return paths, nil
+}
+
+// StartCommand returns the command that runs module:function() with erl once
+// the code paths are loaded. The node keeps running after the call returns.
+func StartCommand(erl string, codePaths []string, module, function string) []string {
+	if function == "" {
+		function = DefaultStartFunction
+	}
+
+	command := []string{erl, "-noshell"}
+	for _, path := range codePaths {
+		command = append(command, "-pa", path)
+	}
+	return append(command, "-s", module, function)
+}
@@ -0,0 +1,119 @@
+package erlang_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SnakeDoc/erlang-cnb"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testPlainProject(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		workingDir string
+	)
+
+	it.Before(func() {
+		workingDir = t.TempDir()
+		Expect(os.MkdirAll(filepath.Join(workingDir, "src"), os.ModePerm)).To(Succeed())
+	})
+
+	context("FindPlainProject", func() {
+		it("finds the sources under src", func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "src", "b.erl"), nil, 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "src", "a.erl"), nil, 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "src", "a.hrl"), nil, 0644)).To(Succeed())
+
+			project, ok, err := erlang.FindPlainProject(workingDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(project).To(Equal(erlang.PlainProject{Sources: []string{"src/a.erl", "src/b.erl"}}))
+		})
+
+		it("prefers an Emakefile", func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "src", "a.erl"), nil, 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "Emakefile"), []byte(`{"src/*", []}.`), 0644)).To(Succeed())
+
+			project, ok, err := erlang.FindPlainProject(workingDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(project).To(Equal(erlang.PlainProject{Emakefile: filepath.Join(workingDir, "Emakefile")}))
+		})
+
+		it("ignores Mix projects", func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "src", "a.erl"), nil, 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "mix.exs"), nil, 0644)).To(Succeed())
+
+			_, ok, err := erlang.FindPlainProject(workingDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+
+		it("reports false without sources", func() {
+			_, ok, err := erlang.FindPlainProject(workingDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	context("CompileArgs", func() {
+		it("runs erl -make for an Emakefile", func() {
+			Expect(erlang.PlainProject{Emakefile: "Emakefile"}.CompileArgs()).To(Equal([]string{"-make"}))
+		})
+
+		it("runs erlc into ebin otherwise", func() {
+			Expect(erlang.PlainProject{Sources: []string{"src/a.erl"}}.CompileArgs()).To(Equal([]string{"-o", "ebin", "-I", "include", "src/a.erl"}))
+		})
+	})
+
+	context("CodePaths", func() {
+		it("is ebin for erlc", func() {
+			paths, err := erlang.PlainProject{Sources: []string{"src/a.erl"}}.CodePaths()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(paths).To(Equal([]string{"ebin"}))
+		})
+
+		it("reads the outdirs of the Emakefile", func() {
+			path := filepath.Join(workingDir, "Emakefile")
+			Expect(os.WriteFile(path, []byte(`
+{"src/*", [debug_info, {outdir, "ebin"}, {i, "include"}]}.
+{"test/*", [{outdir, "ebin/"}]}.
+{"tools/*", []}.
+'scripts/*'.
+`), 0644)).To(Succeed())
+
+			paths, err := erlang.PlainProject{Emakefile: path}.CodePaths()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(paths).To(Equal([]string{"ebin", "."}))
+		})
+
+		context("when the Emakefile is malformed", func() {
+			it("returns an error", func() {
+				path := filepath.Join(workingDir, "Emakefile")
+				Expect(os.WriteFile(path, []byte(`{"src/*", [`), 0644)).To(Succeed())
+
+				_, err := erlang.PlainProject{Emakefile: path}.CodePaths()
+				Expect(err).To(MatchError(ContainSubstring("failed to parse Emakefile")))
+			})
+		})
+	})
+
+	context("StartCommand", func() {
+		it("calls start by default", func() {
+			Expect(erlang.StartCommand("/layers/erlang-launch/bin/erl", []string{"ebin"}, "tool", "")).To(Equal([]string{
+				"/layers/erlang-launch/bin/erl", "-noshell", "-pa", "ebin", "-s", "tool", "start",
+			}))
+		})
+
+		it("calls the given function", func() {
+			Expect(erlang.StartCommand("erl", []string{"ebin", "."}, "tool", "main")).To(Equal([]string{
+				"erl", "-noshell", "-pa", "ebin", "-pa", ".", "-s", "tool", "main",
+			}))
+		})
+	})
+}
@@ -0,0 +1,272 @@
+package erlang
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2/servicebindings"
+)
+
+// VersionPolicyBindingType is the type of the service binding that can carry
+// an organization-wide OTP version policy in its "allowed-versions" and
+// "denied-versions" entries.
+const VersionPolicyBindingType = "erlang-version-policy"
+
+//go:generate faux --interface VersionLister --output fakes/version_lister.go
+type VersionLister interface {
+	ListVersions(arch, ubuntuVersion string) ([]string, error)
+}
+
+//go:generate faux --interface BindingResolver --output fakes/binding_resolver.go
+type BindingResolver interface {
+	Resolve(typ, provider, platformDir string) ([]servicebindings.Binding, error)
+}
+
+// VersionRule is a single allow or deny rule. Expression holds alternatives
+// separated by "||", each made of comparisons separated by commas or spaces
+// that must all hold, e.g. ">=26.2.5, <27 || >=27.3.4".
+type VersionRule struct {
+	Expression string
+
+	// Source names where the rule came from, e.g. BP_ERLANG_ALLOWED_VERSIONS.
+	Source string
+
+	alternatives [][]versionComparison
+}
+
+type versionComparison struct {
+	op      string
+	version []int
+}
+
+// ParseVersionRule parses expression. A comparison is one of >=, >, <=, <, =
+// or != followed by a version, with or without a space in between; a bare
+// version is the same as "=". Equality
+// matches by prefix, so "=26" matches every 26.x release.
+func ParseVersionRule(expression, source string) (VersionRule, error) {
+	rule := VersionRule{Expression: strings.TrimSpace(expression), Source: source}
+
+	for _, alternative := range strings.Split(expression, "||") {
+		var comparisons []versionComparison
+		fields := splitList(alternative)
+		for i := 0; i < len(fields); i++ {
+			field := fields[i]
+
+			// an operator may be separated from its version, as in ">= 26.2.5"
+			if comparisonOperators[field] {
+				if i+1 == len(fields) {
+					return VersionRule{}, fmt.Errorf("invalid version rule %q in %s: operator %q has no version", rule.Expression, source, field)
+				}
+				i++
+				field += fields[i]
+			}
+
+			comparison, err := parseVersionComparison(field)
+			if err != nil {
+				return VersionRule{}, fmt.Errorf("invalid version rule %q in %s: %w", rule.Expression, source, err)
+			}
+			comparisons = append(comparisons, comparison)
+		}
+
+		if len(comparisons) == 0 {
+			return VersionRule{}, fmt.Errorf("invalid version rule %q in %s: empty constraint", rule.Expression, source)
+		}
+		rule.alternatives = append(rule.alternatives, comparisons)
+	}
+
+	return rule, nil
+}
+
+var comparisonOperators = map[string]bool{">=": true, "<=": true, "!=": true, ">": true, "<": true, "=": true}
+
+func parseVersionComparison(text string) (versionComparison, error) {
+	op := "="
+	for _, candidate := range []string{">=", "<=", "!=", ">", "<", "="} {
+		if strings.HasPrefix(text, candidate) {
+			op = candidate
+			break
+		}
+	}
+
+	version, err := parseVersion(strings.TrimPrefix(strings.TrimPrefix(text, op), "OTP-"))
+	if err != nil {
+		return versionComparison{}, fmt.Errorf("%q: %w", text, err)
+	}
+
+	return versionComparison{op: op, version: version}, nil
+}
+
+// Matches reports whether version satisfies any alternative of the rule.
+func (r VersionRule) Matches(version string) bool {
+	ver, err := parseVersion(parseOTPVersion(version))
+	if err != nil {
+		return false
+	}
+
+	for _, comparisons := range r.alternatives {
+		matched := true
+		for _, c := range comparisons {
+			if !c.matches(ver) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c versionComparison) matches(version []int) bool {
+	switch c.op {
+	case ">=":
+		return compareVersions(version, c.version) >= 0
+	case ">":
+		return compareVersions(version, c.version) > 0
+	case "<=":
+		return compareVersions(version, c.version) <= 0
+	case "<":
+		return compareVersions(version, c.version) < 0
+	case "!=":
+		return !hasVersionPrefix(version, c.version)
+	default:
+		return hasVersionPrefix(version, c.version)
+	}
+}
+
+func hasVersionPrefix(version, prefix []int) bool {
+	if len(version) < len(prefix) {
+		return compareVersions(version, prefix) == 0
+	}
+	return compareVersions(version[:len(prefix)], prefix) == 0
+}
+
+// VersionPolicy restricts which OTP versions may be installed. A version is
+// permitted when it matches every allow rule and none of the deny rules.
+type VersionPolicy struct {
+	Allowed []VersionRule
+	Denied  []VersionRule
+}
+
+// LoadVersionPolicy reads the policy from BP_ERLANG_ALLOWED_VERSIONS and
+// BP_ERLANG_DENIED_VERSIONS, and from any erlang-version-policy binding under
+// platformDir. Rules from all sources apply together.
+func LoadVersionPolicy(resolver BindingResolver, platformDir string) (VersionPolicy, error) {
+	var policy VersionPolicy
+
+	add := func(expression, source string, allowed bool) error {
+		if strings.TrimSpace(expression) == "" {
+			return nil
+		}
+
+		rule, err := ParseVersionRule(expression, source)
+		if err != nil {
+			return err
+		}
+
+		if allowed {
+			policy.Allowed = append(policy.Allowed, rule)
+		} else {
+			policy.Denied = append(policy.Denied, rule)
+		}
+		return nil
+	}
+
+	err := add(os.Getenv("BP_ERLANG_ALLOWED_VERSIONS"), "BP_ERLANG_ALLOWED_VERSIONS", true)
+	if err != nil {
+		return VersionPolicy{}, err
+	}
+
+	err = add(os.Getenv("BP_ERLANG_DENIED_VERSIONS"), "BP_ERLANG_DENIED_VERSIONS", false)
+	if err != nil {
+		return VersionPolicy{}, err
+	}
+
+	bindings, err := resolver.Resolve(VersionPolicyBindingType, "", platformDir)
+	if err != nil {
+		return VersionPolicy{}, fmt.Errorf("failed to resolve %s bindings: %w", VersionPolicyBindingType, err)
+	}
+
+	for _, binding := range bindings {
+		for _, key := range []string{"allowed-versions", "denied-versions"} {
+			entry, ok := binding.Entries[key]
+			if !ok {
+				continue
+			}
+
+			content, err := entry.ReadString()
+			if err != nil {
+				return VersionPolicy{}, fmt.Errorf("failed to read %s from binding %s: %w", key, binding.Name, err)
+			}
+
+			// each non-empty line is a separate rule; lines starting with #
+			// are comments
+			for _, line := range strings.Split(content, "\n") {
+				line = strings.TrimSpace(line)
+				if line == "" || strings.HasPrefix(line, "#") {
+					continue
+				}
+
+				err = add(line, fmt.Sprintf("binding %s (%s)", binding.Name, key), key == "allowed-versions")
+				if err != nil {
+					return VersionPolicy{}, err
+				}
+			}
+		}
+	}
+
+	return policy, nil
+}
+
+func (p VersionPolicy) IsEmpty() bool {
+	return len(p.Allowed) == 0 && len(p.Denied) == 0
+}
+
+// Violation returns the first rule that version breaks and whether it was an
+// allow rule, or ok=false when version is permitted.
+func (p VersionPolicy) Violation(version string) (rule VersionRule, allowRule bool, ok bool) {
+	for _, rule := range p.Allowed {
+		if !rule.Matches(version) {
+			return rule, true, true
+		}
+	}
+
+	for _, rule := range p.Denied {
+		if rule.Matches(version) {
+			return rule, false, true
+		}
+	}
+
+	return VersionRule{}, false, false
+}
+
+func (p VersionPolicy) Permits(version string) bool {
+	_, _, violated := p.Violation(version)
+	return !violated
+}
+
+// Check returns an error naming the broken rule when version is not permitted.
+// The newest of available that the policy permits is suggested, if any.
+func (p VersionPolicy) Check(version string, available []string) error {
+	rule, allowRule, violated := p.Violation(version)
+	if !violated {
+		return nil
+	}
+
+	var reason string
+	if allowRule {
+		reason = fmt.Sprintf("it does not match the allowed versions %q from %s", rule.Expression, rule.Source)
+	} else {
+		reason = fmt.Sprintf("it matches the denied versions %q from %s", rule.Expression, rule.Source)
+	}
+
+	suggestion := newestVersion(available, p.Permits)
+	if suggestion == "" {
+		return fmt.Errorf("version policy does not permit Erlang %s: %s", version, reason)
+	}
+
+	return fmt.Errorf("version policy does not permit Erlang %s: %s; the newest permitted version is %s (set BP_ERLANG_VERSION=%s)", version, reason, suggestion, suggestion)
+}
@@ -0,0 +1,170 @@
+package erlang_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/SnakeDoc/erlang-cnb"
+	"github.com/SnakeDoc/erlang-cnb/fakes"
+	"github.com/paketo-buildpacks/packit/v2/servicebindings"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testPolicy(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("ParseVersionRule", func() {
+		it("compares versions numerically", func() {
+			rule, err := erlang.ParseVersionRule(">=26.2.5", "test")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(rule.Matches("26.2.5")).To(BeTrue())
+			Expect(rule.Matches("26.2.5.11")).To(BeTrue())
+			Expect(rule.Matches("26.10")).To(BeTrue())
+			Expect(rule.Matches("26.2.4")).To(BeFalse())
+		})
+
+		it("requires every comparison of an alternative to hold", func() {
+			rule, err := erlang.ParseVersionRule(">=26, <27 || >=28", "test")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(rule.Matches("26.2")).To(BeTrue())
+			Expect(rule.Matches("27.3.4")).To(BeFalse())
+			Expect(rule.Matches("28.1")).To(BeTrue())
+		})
+
+		it("matches bare versions by prefix", func() {
+			rule, err := erlang.ParseVersionRule("27.3", "test")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(rule.Matches("27.3")).To(BeTrue())
+			Expect(rule.Matches("OTP-27.3.4")).To(BeTrue())
+			Expect(rule.Matches("27.30")).To(BeFalse())
+		})
+
+		it("supports inequality", func() {
+			rule, err := erlang.ParseVersionRule("!=27", "test")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(rule.Matches("27.3")).To(BeFalse())
+			Expect(rule.Matches("26.2")).To(BeTrue())
+		})
+
+		it("accepts a space between an operator and its version", func() {
+			rule, err := erlang.ParseVersionRule(">= 26.2.5, < 27", "BP_ERLANG_ALLOWED_VERSIONS")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(rule.Matches("26.2.5")).To(BeTrue())
+			Expect(rule.Matches("26.2.4")).To(BeFalse())
+			Expect(rule.Matches("27.1")).To(BeFalse())
+		})
+
+		it("rejects an operator without a version", func() {
+			_, err := erlang.ParseVersionRule(">=26, <", "BP_ERLANG_ALLOWED_VERSIONS")
+			Expect(err).To(MatchError(ContainSubstring(`operator "<" has no version`)))
+		})
+
+		it("rejects malformed rules", func() {
+			_, err := erlang.ParseVersionRule(">=26.x", "BP_ERLANG_ALLOWED_VERSIONS")
+			Expect(err).To(MatchError(ContainSubstring(`invalid version rule ">=26.x" in BP_ERLANG_ALLOWED_VERSIONS`)))
+
+			_, err = erlang.ParseVersionRule(">=26 ||", "test")
+			Expect(err).To(MatchError(ContainSubstring("empty constraint")))
+		})
+	})
+
+	context("VersionPolicy", func() {
+		var policy erlang.VersionPolicy
+
+		it.Before(func() {
+			allowed, err := erlang.ParseVersionRule(">=26", "allow-source")
+			Expect(err).NotTo(HaveOccurred())
+			denied, err := erlang.ParseVersionRule("27.3.2", "deny-source")
+			Expect(err).NotTo(HaveOccurred())
+
+			policy = erlang.VersionPolicy{
+				Allowed: []erlang.VersionRule{allowed},
+				Denied:  []erlang.VersionRule{denied},
+			}
+		})
+
+		it("permits versions that match every allow rule and no deny rule", func() {
+			Expect(policy.Permits("27.3.3")).To(BeTrue())
+			Expect(policy.Permits("25.3")).To(BeFalse())
+			Expect(policy.Permits("27.3.2")).To(BeFalse())
+			Expect(policy.Check("27.3.3", nil)).To(Succeed())
+		})
+
+		it("names the broken rule and suggests the newest permitted version", func() {
+			err := policy.Check("27.3.2", []string{"25.3.2", "27.3.2", "27.3.3", "26.2.5"})
+			Expect(err).To(MatchError(`version policy does not permit Erlang 27.3.2: it matches the denied versions "27.3.2" from deny-source; the newest permitted version is 27.3.3 (set BP_ERLANG_VERSION=27.3.3)`))
+
+			err = policy.Check("25.3", nil)
+			Expect(err).To(MatchError(`version policy does not permit Erlang 25.3: it does not match the allowed versions ">=26" from allow-source`))
+		})
+	})
+
+	context("LoadVersionPolicy", func() {
+		var resolver *fakes.BindingResolver
+
+		it.Before(func() {
+			resolver = &fakes.BindingResolver{}
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_ERLANG_ALLOWED_VERSIONS")).To(Succeed())
+			Expect(os.Unsetenv("BP_ERLANG_DENIED_VERSIONS")).To(Succeed())
+		})
+
+		it("returns an empty policy by default", func() {
+			policy, err := erlang.LoadVersionPolicy(resolver, "platform")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(policy.IsEmpty()).To(BeTrue())
+			Expect(resolver.ResolveCall.Receives.Typ).To(Equal("erlang-version-policy"))
+			Expect(resolver.ResolveCall.Receives.PlatformDir).To(Equal("platform"))
+		})
+
+		it("reads rules from the environment and bindings", func() {
+			Expect(os.Setenv("BP_ERLANG_ALLOWED_VERSIONS", ">=26.2.5")).To(Succeed())
+			Expect(os.Setenv("BP_ERLANG_DENIED_VERSIONS", "27.0")).To(Succeed())
+			resolver.ResolveCall.Returns.BindingSlice = []servicebindings.Binding{
+				{
+					Name: "policy",
+					Entries: map[string]*servicebindings.Entry{
+						"allowed-versions": servicebindings.NewWithValue([]byte("# org baseline\n<29\n")),
+						"denied-versions":  servicebindings.NewWithValue([]byte("26.2.5.1\n\n26.2.5.2\n")),
+					},
+				},
+			}
+
+			policy, err := erlang.LoadVersionPolicy(resolver, "platform")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(policy.Allowed).To(HaveLen(2))
+			Expect(policy.Allowed[1].Source).To(Equal("binding policy (allowed-versions)"))
+			Expect(policy.Denied).To(HaveLen(3))
+
+			Expect(policy.Permits("28.1")).To(BeTrue())
+			Expect(policy.Permits("29.0")).To(BeFalse())
+			Expect(policy.Permits("27.0.1")).To(BeFalse())
+			Expect(policy.Permits("26.2.5.2")).To(BeFalse())
+		})
+
+		it("returns an error when bindings cannot be resolved", func() {
+			resolver.ResolveCall.Returns.Error = errors.New("bad binding")
+
+			_, err := erlang.LoadVersionPolicy(resolver, "platform")
+			Expect(err).To(MatchError("failed to resolve erlang-version-policy bindings: bad binding"))
+		})
+
+		it("returns an error for malformed rules", func() {
+			Expect(os.Setenv("BP_ERLANG_DENIED_VERSIONS", "~>27")).To(Succeed())
+
+			_, err := erlang.LoadVersionPolicy(resolver, "platform")
+			Expect(err).To(MatchError(ContainSubstring("in BP_ERLANG_DENIED_VERSIONS")))
+		})
+	})
+}
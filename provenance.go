@@ -0,0 +1,135 @@
+package erlang
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	// ProvenanceFile is the name of the provenance statement written into
+	// the root of the erlang layer. It reaches the image with the launch
+	// layer, which is assembled from the erlang layer. No copy is written
+	// next to the SBOM files: the lifecycle only exports <layer>.sbom.<ext>
+	// files in the CycloneDX, SPDX and Syft formats, so an in-toto statement
+	// there would never leave the build.
+	ProvenanceFile = "provenance.intoto.json"
+
+	InTotoStatementType     = "https://in-toto.io/Statement/v1"
+	SLSAProvenancePredicate = "https://slsa.dev/provenance/v1"
+	ProvenanceBuildType     = "https://github.com/SnakeDoc/erlang-cnb/provenance/v1"
+)
+
+// Provenance records where the OTP runtime installed into a layer came from.
+type Provenance struct {
+	Version string
+
+	// VersionSource is BP_ERLANG_VERSION for a pinned version, or builds.txt
+	// when the latest release was resolved. A version in .tool-versions
+	// does not select what is installed, so it never appears here.
+	VersionSource string
+
+	Arch          string
+	UbuntuVersion string
+
+	// Index is the builds.txt the version was resolved from, if any.
+	Index IndexDigest
+
+	TarballURL    string
+	TarballSHA256 string
+
+	// BuiltAt is the SOURCE_DATE_EPOCH of the build rather than the wall
+	// clock, so that the statement does not change the layer digests. The
+	// startedOn it is rendered as is therefore always 1980-01-01T00:00:01Z
+	// unless SOURCE_DATE_EPOCH is set.
+	BuiltAt time.Time
+
+	BuildpackID      string
+	BuildpackVersion string
+}
+
+type inTotoStatement struct {
+	Type          string           `json:"_type"`
+	Subject       []resourceDesc   `json:"subject"`
+	PredicateType string           `json:"predicateType"`
+	Predicate     slsaProvenanceV1 `json:"predicate"`
+}
+
+type resourceDesc struct {
+	Name   string            `json:"name,omitempty"`
+	URI    string            `json:"uri,omitempty"`
+	Digest map[string]string `json:"digest"`
+}
+
+type slsaProvenanceV1 struct {
+	BuildDefinition struct {
+		BuildType            string            `json:"buildType"`
+		ExternalParameters   map[string]string `json:"externalParameters"`
+		ResolvedDependencies []resourceDesc    `json:"resolvedDependencies"`
+	} `json:"buildDefinition"`
+	RunDetails struct {
+		Builder struct {
+			ID      string            `json:"id"`
+			Version map[string]string `json:"version"`
+		} `json:"builder"`
+		Metadata struct {
+			StartedOn string `json:"startedOn"`
+		} `json:"metadata"`
+	} `json:"runDetails"`
+}
+
+// Statement renders p as an in-toto statement carrying a SLSA v1 provenance
+// predicate. The subject is the OTP tarball the layer was installed from.
+func (p Provenance) Statement() ([]byte, error) {
+	tarball := resourceDesc{
+		Name:   fmt.Sprintf("erlang-otp-%s.tar.gz", p.Version),
+		URI:    p.TarballURL,
+		Digest: map[string]string{"sha256": p.TarballSHA256},
+	}
+
+	statement := inTotoStatement{
+		Type:          InTotoStatementType,
+		Subject:       []resourceDesc{tarball},
+		PredicateType: SLSAProvenancePredicate,
+	}
+
+	definition := &statement.Predicate.BuildDefinition
+	definition.BuildType = ProvenanceBuildType
+	definition.ExternalParameters = map[string]string{
+		"version":       p.Version,
+		"versionSource": p.VersionSource,
+		"arch":          p.Arch,
+		"ubuntuVersion": p.UbuntuVersion,
+	}
+	definition.ResolvedDependencies = []resourceDesc{tarball}
+	if p.Index.URL != "" {
+		definition.ResolvedDependencies = append(definition.ResolvedDependencies, resourceDesc{
+			Name:   "builds.txt",
+			URI:    p.Index.URL,
+			Digest: map[string]string{"sha256": p.Index.SHA256},
+		})
+	}
+
+	run := &statement.Predicate.RunDetails
+	run.Builder.ID = p.BuildpackID
+	run.Builder.Version = map[string]string{p.BuildpackID: p.BuildpackVersion}
+	run.Metadata.StartedOn = p.BuiltAt.UTC().Format(time.RFC3339)
+
+	return json.MarshalIndent(statement, "", "  ")
+}
+
+// WriteProvenance writes the statement for p to path.
+func WriteProvenance(path string, p Provenance) error {
+	content, err := p.Statement()
+	if err != nil {
+		return fmt.Errorf("failed to render provenance statement: %w", err)
+	}
+
+	err = os.WriteFile(path, append(content, '\n'), 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write provenance statement: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package erlang_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SnakeDoc/erlang-cnb"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testProvenance(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		provenance erlang.Provenance
+	)
+
+	it.Before(func() {
+		provenance = erlang.Provenance{
+			Version:       "28.1.1",
+			VersionSource: "builds.txt",
+			Arch:          "arm64",
+			UbuntuVersion: "ubuntu-24.04",
+			Index: erlang.IndexDigest{
+				URL:    "https://builds.hex.pm/builds/otp/arm64/ubuntu-24.04/builds.txt",
+				SHA256: "index-sha256",
+			},
+			TarballURL:       "https://builds.hex.pm/builds/otp/arm64/ubuntu-24.04/OTP-28.1.1.tar.gz",
+			TarballSHA256:    "tarball-sha256",
+			BuiltAt:          time.Date(2025, time.October, 20, 14, 0, 0, 0, time.FixedZone("CEST", 2*60*60)),
+			BuildpackID:      "snakedoc/erlang",
+			BuildpackVersion: "1.2.3",
+		}
+	})
+
+	context("Statement", func() {
+		it("records the resolved builds.txt as a dependency", func() {
+			content, err := provenance.Statement()
+			Expect(err).NotTo(HaveOccurred())
+
+			var statement struct {
+				Subject []struct {
+					URI    string            `json:"uri"`
+					Digest map[string]string `json:"digest"`
+				} `json:"subject"`
+				Predicate struct {
+					BuildDefinition struct {
+						ExternalParameters   map[string]string `json:"externalParameters"`
+						ResolvedDependencies []struct {
+							Name   string            `json:"name"`
+							URI    string            `json:"uri"`
+							Digest map[string]string `json:"digest"`
+						} `json:"resolvedDependencies"`
+					} `json:"buildDefinition"`
+					RunDetails struct {
+						Metadata struct {
+							StartedOn string `json:"startedOn"`
+						} `json:"metadata"`
+					} `json:"runDetails"`
+				} `json:"predicate"`
+			}
+			Expect(json.Unmarshal(content, &statement)).To(Succeed())
+
+			Expect(statement.Subject).To(HaveLen(1))
+			Expect(statement.Subject[0].Digest).To(Equal(map[string]string{"sha256": "tarball-sha256"}))
+
+			definition := statement.Predicate.BuildDefinition
+			Expect(definition.ExternalParameters).To(HaveKeyWithValue("versionSource", "builds.txt"))
+			Expect(definition.ResolvedDependencies).To(HaveLen(2))
+			Expect(definition.ResolvedDependencies[1].Name).To(Equal("builds.txt"))
+			Expect(definition.ResolvedDependencies[1].URI).To(Equal("https://builds.hex.pm/builds/otp/arm64/ubuntu-24.04/builds.txt"))
+			Expect(definition.ResolvedDependencies[1].Digest).To(Equal(map[string]string{"sha256": "index-sha256"}))
+
+			Expect(statement.Predicate.RunDetails.Metadata.StartedOn).To(Equal("2025-10-20T12:00:00Z"))
+		})
+	})
+
+	context("WriteProvenance", func() {
+		it("writes the statement to the given path", func() {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "provenance.intoto.json")
+
+			Expect(erlang.WriteProvenance(path, provenance)).To(Succeed())
+
+			content, err := os.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+
+			expected, err := provenance.Statement()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(content).To(Equal(append(expected, '\n')))
+		})
+
+		context("when the file cannot be written", func() {
+			it("returns an error", func() {
+				err := erlang.WriteProvenance(filepath.Join(t.TempDir(), "missing", "provenance.intoto.json"), provenance)
+				Expect(err).To(MatchError(ContainSubstring("failed to write provenance statement")))
+			})
+		})
+	})
+}
@@ -0,0 +1,241 @@
+package erlang
+
+import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	Rebar3               = "rebar3"
+	Rebar3LayerName      = "rebar3"
+	Rebar3CacheLayerName = "rebar3-cache"
+
+	Rebar3ReleasesURL = "https://api.github.com/repos/erlang/rebar3/releases"
+)
+
+//go:embed rebar3_compat.toml
+var rebar3CompatTOML string
+
+// Rebar3Release is a published rebar3 escript.
+type Rebar3Release struct {
+	Version string
+	URL     string
+	SHA256  string
+}
+
+//go:generate faux --interface Rebar3Installer --output fakes/rebar3_installer.go
+type Rebar3Installer interface {
+	ResolveRelease(version string) (Rebar3Release, error)
+	Install(release Rebar3Release, layerPath string) error
+}
+
+// Rebar3Downloader installs rebar3 escripts published as GitHub releases.
+type Rebar3Downloader struct {
+	releasesURL string
+}
+
+func NewRebar3Downloader() Rebar3Downloader {
+	return Rebar3Downloader{releasesURL: Rebar3ReleasesURL}
+}
+
+// WithReleasesURL returns a copy of the downloader that queries url instead of
+// the GitHub releases API.
+func (d Rebar3Downloader) WithReleasesURL(url string) Rebar3Downloader {
+	d.releasesURL = url
+	return d
+}
+
+// ResolveRelease looks up the escript for version, or for the latest release
+// when version is empty. GitHub publishes a SHA-256 digest for every release
+// asset; a release without one cannot be verified and is rejected.
+func (d Rebar3Downloader) ResolveRelease(version string) (Rebar3Release, error) {
+	url := d.releasesURL + "/latest"
+	if version != "" {
+		url = d.releasesURL + "/tags/" + version
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return Rebar3Release{}, fmt.Errorf("failed to fetch rebar3 release from %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Rebar3Release{}, fmt.Errorf("failed to fetch rebar3 release from %s: received status code %d", url, resp.StatusCode)
+	}
+
+	var release struct {
+		TagName string `json:"tag_name"`
+		Assets  []struct {
+			Name               string `json:"name"`
+			BrowserDownloadURL string `json:"browser_download_url"`
+			Digest             string `json:"digest"`
+		} `json:"assets"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&release)
+	if err != nil {
+		return Rebar3Release{}, fmt.Errorf("failed to parse rebar3 release from %s: %w", url, err)
+	}
+
+	for _, asset := range release.Assets {
+		if asset.Name != "rebar3" {
+			continue
+		}
+
+		algorithm, digest, _ := strings.Cut(asset.Digest, ":")
+		if algorithm != "sha256" || digest == "" {
+			return Rebar3Release{}, fmt.Errorf("rebar3 %s has no published SHA-256 checksum", release.TagName)
+		}
+
+		return Rebar3Release{
+			Version: release.TagName,
+			URL:     asset.BrowserDownloadURL,
+			SHA256:  strings.ToLower(digest),
+		}, nil
+	}
+
+	return Rebar3Release{}, fmt.Errorf("rebar3 %s has no rebar3 escript", release.TagName)
+}
+
+// Install downloads the escript of release into bin/rebar3 under layerPath.
+// The file only appears there once its checksum matches.
+func (d Rebar3Downloader) Install(release Rebar3Release, layerPath string) error {
+	binDir := filepath.Join(layerPath, "bin")
+	err := os.MkdirAll(binDir, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", binDir, err)
+	}
+
+	file, err := os.CreateTemp(binDir, ".rebar3-download-*")
+	if err != nil {
+		return fmt.Errorf("failed to create download file in %s: %w", binDir, err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	resp, err := http.Get(release.URL)
+	if err != nil {
+		return fmt.Errorf("failed to download rebar3 from %s: %w", release.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download rebar3 from %s: received status code %d", release.URL, resp.StatusCode)
+	}
+
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(file, hash), resp.Body)
+	if err != nil {
+		return diskSpaceError(fmt.Errorf("failed to download rebar3 from %s: %w", release.URL, err))
+	}
+
+	sum := hex.EncodeToString(hash.Sum(nil))
+	if sum != release.SHA256 {
+		return fmt.Errorf("checksum mismatch for rebar3 %s: expected %s, got %s", release.Version, release.SHA256, sum)
+	}
+
+	err = file.Chmod(0755)
+	if err != nil {
+		return fmt.Errorf("failed to make rebar3 executable: %w", err)
+	}
+
+	err = os.Rename(file.Name(), filepath.Join(binDir, "rebar3"))
+	if err != nil {
+		return fmt.Errorf("failed to move rebar3 into %s: %w", binDir, err)
+	}
+
+	return nil
+}
+
+// Rebar3Compatibility is the range of OTP majors a rebar3 minor release
+// supports.
+type Rebar3Compatibility struct {
+	Rebar3 string `toml:"rebar3"`
+	MinOTP int    `toml:"min-otp"`
+	MaxOTP int    `toml:"max-otp"`
+}
+
+type rebar3CompatTable struct {
+	Releases []Rebar3Compatibility `toml:"releases"`
+}
+
+// LookupRebar3Compatibility returns the supported OTP range for the minor
+// release of rebar3Version, and false when the buildpack has no data for it.
+func LookupRebar3Compatibility(rebar3Version string) (Rebar3Compatibility, bool, error) {
+	var table rebar3CompatTable
+	_, err := toml.Decode(rebar3CompatTOML, &table)
+	if err != nil {
+		return Rebar3Compatibility{}, false, fmt.Errorf("failed to parse rebar3 compatibility table: %w", err)
+	}
+
+	ver, err := parseVersion(rebar3Version)
+	if err != nil || len(ver) < 2 {
+		return Rebar3Compatibility{}, false, nil
+	}
+	minor := fmt.Sprintf("%d.%d", ver[0], ver[1])
+
+	for _, release := range table.Releases {
+		if release.Rebar3 == minor {
+			return release, true, nil
+		}
+	}
+
+	return Rebar3Compatibility{}, false, nil
+}
+
+// CheckOTP returns an error when otpVersion is older than the oldest major the
+// rebar3 release runs on.
+func (c Rebar3Compatibility) CheckOTP(otpVersion string) error {
+	ver, err := parseVersion(parseOTPVersion(otpVersion))
+	if err != nil {
+		return nil
+	}
+
+	if ver[0] < c.MinOTP {
+		return fmt.Errorf("rebar3 %s requires OTP %d or newer, but OTP %s is installed", c.Rebar3, c.MinOTP, otpVersion)
+	}
+
+	return nil
+}
+
+// IsTestedWith reports whether otpVersion is within the majors the rebar3
+// release was tested against.
+func (c Rebar3Compatibility) IsTestedWith(otpVersion string) bool {
+	ver, err := parseVersion(parseOTPVersion(otpVersion))
+	if err != nil {
+		return true
+	}
+	return ver[0] >= c.MinOTP && ver[0] <= c.MaxOTP
+}
+
+// verifyFileSHA256 returns an error unless the file at path has the given
+// SHA-256 checksum.
+func verifyFileSHA256(path, expected string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return err
+	}
+
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != expected {
+		return fmt.Errorf("checksum of %s does not match: expected %s, got %s", path, expected, sum)
+	}
+
+	return nil
+}
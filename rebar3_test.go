@@ -0,0 +1,149 @@
+package erlang_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SnakeDoc/erlang-cnb"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testRebar3(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		server     *httptest.Server
+		downloader erlang.Rebar3Downloader
+		escript    []byte
+		checksum   string
+		digest     string
+	)
+
+	it.Before(func() {
+		escript = []byte("#!/usr/bin/env escript\nrebar3")
+		sum := sha256.Sum256(escript)
+		checksum = hex.EncodeToString(sum[:])
+		digest = "sha256:" + checksum
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/releases/latest", "/releases/tags/3.25.1":
+				fmt.Fprintf(w, `{
+					"tag_name": "3.25.1",
+					"assets": [
+						{"name": "rebar3.sha256", "browser_download_url": "https://example.com/rebar3.sha256"},
+						{"name": "rebar3", "browser_download_url": "%s/download/rebar3", "digest": %q}
+					]
+				}`, "http://"+r.Host, digest)
+			case "/releases/tags/3.0.0":
+				fmt.Fprint(w, `{"tag_name": "3.0.0", "assets": [{"name": "rebar3", "browser_download_url": "https://example.com/rebar3"}]}`)
+			case "/download/rebar3":
+				_, _ = w.Write(escript)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+
+		downloader = erlang.NewRebar3Downloader().WithReleasesURL(server.URL + "/releases")
+	})
+
+	it.After(func() {
+		server.Close()
+	})
+
+	context("ResolveRelease", func() {
+		it("resolves the latest release", func() {
+			release, err := downloader.ResolveRelease("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(release).To(Equal(erlang.Rebar3Release{
+				Version: "3.25.1",
+				URL:     server.URL + "/download/rebar3",
+				SHA256:  checksum,
+			}))
+		})
+
+		it("resolves a pinned release", func() {
+			release, err := downloader.ResolveRelease("3.25.1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(release.Version).To(Equal("3.25.1"))
+		})
+
+		context("when the release has no published checksum", func() {
+			it("returns an error", func() {
+				_, err := downloader.ResolveRelease("3.0.0")
+				Expect(err).To(MatchError("rebar3 3.0.0 has no published SHA-256 checksum"))
+			})
+		})
+
+		context("when the release does not exist", func() {
+			it("returns an error", func() {
+				_, err := downloader.ResolveRelease("9.9.9")
+				Expect(err).To(MatchError(ContainSubstring("received status code 404")))
+			})
+		})
+	})
+
+	context("Install", func() {
+		var layerPath string
+
+		it.Before(func() {
+			layerPath = filepath.Join(t.TempDir(), "rebar3")
+		})
+
+		it("installs the verified escript", func() {
+			release, err := downloader.ResolveRelease("")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(downloader.Install(release, layerPath)).To(Succeed())
+
+			info, err := os.Stat(filepath.Join(layerPath, "bin", "rebar3"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0755)))
+
+			content, err := os.ReadFile(filepath.Join(layerPath, "bin", "rebar3"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(content).To(Equal(escript))
+		})
+
+		context("when the checksum does not match", func() {
+			it("returns an error and installs nothing", func() {
+				release := erlang.Rebar3Release{Version: "3.25.1", URL: server.URL + "/download/rebar3", SHA256: "0000"}
+
+				err := downloader.Install(release, layerPath)
+				Expect(err).To(MatchError(fmt.Sprintf("checksum mismatch for rebar3 3.25.1: expected 0000, got %s", checksum)))
+
+				entries, err := os.ReadDir(filepath.Join(layerPath, "bin"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(entries).To(BeEmpty())
+			})
+		})
+	})
+
+	context("LookupRebar3Compatibility", func() {
+		it("finds the range for the minor release", func() {
+			compat, known, err := erlang.LookupRebar3Compatibility("3.25.1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(known).To(BeTrue())
+			Expect(compat.MinOTP).To(Equal(26))
+
+			Expect(compat.CheckOTP("27.3.4")).To(Succeed())
+			Expect(compat.IsTestedWith("28.1.1")).To(BeTrue())
+			Expect(compat.IsTestedWith("29.0")).To(BeFalse())
+			Expect(compat.CheckOTP("25.3.2")).To(MatchError("rebar3 3.25 requires OTP 26 or newer, but OTP 25.3.2 is installed"))
+		})
+
+		it("reports unknown releases", func() {
+			_, known, err := erlang.LookupRebar3Compatibility("4.0.0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(known).To(BeFalse())
+		})
+	})
+}
@@ -0,0 +1,172 @@
+package erlang
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LockedDependency is a dependency pinned by a lock file.
+type LockedDependency struct {
+	Name    string
+	Version string
+
+	// Package is the name of the package a hex dependency is fetched as. It
+	// differs from Name when the dependency is an alias.
+	Package string
+
+	// Source is one of "hex", "git" or "path".
+	Source string
+
+	// URL is the repository URL of git dependencies or the location of path
+	// dependencies.
+	URL string
+
+	// Ref is the git ref a git dependency is locked to.
+	Ref string
+
+	// SHA256 is the checksum of the package tarball (the "outer" checksum)
+	// and InnerSHA256 the checksum of its contents, both lowercase hex.
+	SHA256      string
+	InnerSHA256 string
+}
+
+// RebarLockDigest returns the SHA-256 of the rebar.lock at path as lowercase
+// hex, or an empty string when there is no lock file.
+func RebarLockDigest(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read rebar.lock: %w", err)
+	}
+
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ParseRebarLock reads the dependencies pinned in a rebar.lock file. A missing
+// file yields no dependencies.
+func ParseRebarLock(path string) ([]LockedDependency, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	terms, err := ParseTerms(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	// rebar3 >= 3.5 writes {Version, Deps}. followed by a list of hash
+	// sections; older releases wrote the bare list of deps.
+	var entries List
+	switch lock := terms[0].(type) {
+	case Tuple:
+		if len(lock) != 2 {
+			return nil, fmt.Errorf("failed to parse %s: unexpected lock term", path)
+		}
+		entries, _ = lock[1].(List)
+	case List:
+		entries = lock
+	default:
+		return nil, fmt.Errorf("failed to parse %s: unexpected lock term", path)
+	}
+
+	hashes := map[string]string{}
+	extHashes := map[string]string{}
+	if len(terms) > 1 {
+		sections, _ := terms[1].(List)
+		if value, ok := proplistValue(sections, "pkg_hash"); ok {
+			hashes = rebarHashes(value)
+		}
+		if value, ok := proplistValue(sections, "pkg_hash_ext"); ok {
+			extHashes = rebarHashes(value)
+		}
+	}
+
+	var deps []LockedDependency
+	for _, entry := range entries {
+		tuple, ok := entry.(Tuple)
+		if !ok || len(tuple) < 2 {
+			return nil, fmt.Errorf("failed to parse %s: unexpected dependency %v", path, entry)
+		}
+
+		name := termString(tuple[0])
+		source, _ := tuple[1].(Tuple)
+		if len(source) == 0 {
+			return nil, fmt.Errorf("failed to parse %s: dependency %s has no source", path, name)
+		}
+
+		dep := LockedDependency{Name: name}
+		switch source[0] {
+		case Atom("pkg"):
+			if len(source) < 2 {
+				return nil, fmt.Errorf("failed to parse %s: dependency %s has no package name", path, name)
+			}
+			dep.Source = "hex"
+			dep.Package = termString(source[1])
+			if len(source) >= 3 {
+				dep.Version = termString(source[2])
+			}
+			dep.InnerSHA256 = hashes[name]
+			dep.SHA256 = extHashes[name]
+
+		case Atom("git"), Atom("git_subdir"):
+			dep.Source = "git"
+			if len(source) >= 2 {
+				dep.URL = termString(source[1])
+			}
+			if len(source) >= 3 {
+				if ref, ok := source[2].(Tuple); ok && len(ref) == 2 {
+					dep.Ref = termString(ref[1])
+				}
+			}
+			dep.Version = dep.Ref
+
+		default:
+			return nil, fmt.Errorf("failed to parse %s: dependency %s has unsupported source %v", path, name, source[0])
+		}
+
+		deps = append(deps, dep)
+	}
+
+	return deps, nil
+}
+
+func rebarHashes(value any) map[string]string {
+	hashes := map[string]string{}
+
+	list, _ := value.(List)
+	for _, item := range list {
+		tuple, ok := item.(Tuple)
+		if ok && len(tuple) == 2 {
+			hashes[termString(tuple[0])] = strings.ToLower(termString(tuple[1]))
+		}
+	}
+
+	return hashes
+}
+
+// termString returns the text of a binary, string or atom term.
+func termString(term any) string {
+	switch v := term.(type) {
+	case Binary:
+		return string(v)
+	case string:
+		return v
+	case Atom:
+		return string(v)
+	}
+	return ""
+}
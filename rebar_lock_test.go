@@ -0,0 +1,158 @@
+package erlang_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SnakeDoc/erlang-cnb"
+	"github.com/paketo-buildpacks/packit/v2/sbom"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testRebarLock(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		workingDir string
+		path       string
+	)
+
+	it.Before(func() {
+		var err error
+		workingDir, err = os.MkdirTemp("", "working-dir")
+		Expect(err).NotTo(HaveOccurred())
+
+		path = filepath.Join(workingDir, "rebar.lock")
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	it("parses hex and git dependencies with their hashes", func() {
+		Expect(os.WriteFile(path, []byte(`{"1.2.0",
+[{<<"cowboy">>,{pkg,<<"cowboy">>,<<"2.10.0">>},0},
+ {<<"mylib">>,
+  {git,"https://github.com/acme/mylib.git",
+       {ref,"4a0b6e3f2c1d"}},
+  0}]}.
+[
+{pkg_hash,[
+ {<<"cowboy">>, <<"FF9FFEFF91DAE4AE270DD975642997AFE2A1179D94B1887863E43F681A203E26">>}]},
+{pkg_hash_ext,[
+ {<<"cowboy">>, <<"3AFDCCB7183CC6F143CB14D3CF51FA00E53DB9EC80CDCD525482F5E99BC41D6B">>}]}
+].
+`), 0644)).To(Succeed())
+
+		deps, err := erlang.ParseRebarLock(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deps).To(Equal([]erlang.LockedDependency{
+			{
+				Name:        "cowboy",
+				Version:     "2.10.0",
+				Package:     "cowboy",
+				Source:      "hex",
+				SHA256:      "3afdccb7183cc6f143cb14d3cf51fa00e53db9ec80cdcd525482f5e99bc41d6b",
+				InnerSHA256: "ff9ffeff91dae4ae270dd975642997afe2a1179d94b1887863e43f681a203e26",
+			},
+			{
+				Name:    "mylib",
+				Version: "4a0b6e3f2c1d",
+				Source:  "git",
+				URL:     "https://github.com/acme/mylib.git",
+				Ref:     "4a0b6e3f2c1d",
+			},
+		}))
+	})
+
+	it("parses the legacy lock format", func() {
+		Expect(os.WriteFile(path, []byte(`[{<<"jsx">>,{pkg,<<"jsx">>,<<"3.1.0">>},0}].`), 0644)).To(Succeed())
+
+		deps, err := erlang.ParseRebarLock(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deps).To(Equal([]erlang.LockedDependency{
+			{Name: "jsx", Version: "3.1.0", Package: "jsx", Source: "hex"},
+		}))
+	})
+
+	it("reads the package name of aliased dependencies", func() {
+		Expect(os.WriteFile(path, []byte(`{"1.2.0",
+[{<<"json">>,{pkg,<<"jsone">>,<<"1.8.1">>},0}]}.
+[
+{pkg_hash,[
+ {<<"json">>, <<"4E4D1C6D1F8E4C3A6F0A1F0D1B6C9E0E0D6E7B0C2D3A4F5E6D7C8B9A0F1E2D3C">>}]}
+].
+`), 0644)).To(Succeed())
+
+		deps, err := erlang.ParseRebarLock(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deps).To(Equal([]erlang.LockedDependency{
+			{
+				Name:        "json",
+				Version:     "1.8.1",
+				Package:     "jsone",
+				Source:      "hex",
+				InnerSHA256: "4e4d1c6d1f8e4c3a6f0a1f0d1b6c9e0e0d6e7b0c2d3a4f5e6d7c8b9a0f1e2d3c",
+			},
+		}))
+
+		bom, err := erlang.GenerateDependencySBOM(path, deps)
+		Expect(err).NotTo(HaveOccurred())
+
+		formatter, err := bom.InFormats(sbom.SyftFormat)
+		Expect(err).NotTo(HaveOccurred())
+
+		content, err := io.ReadAll(formatter.Formats()[0].Content)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(content)).To(ContainSubstring(`"purl":"pkg:hex/jsone@1.8.1"`))
+	})
+
+	it("returns nothing when the lock file does not exist", func() {
+		deps, err := erlang.ParseRebarLock(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deps).To(BeEmpty())
+	})
+
+	context("RebarLockDigest", func() {
+		it("returns the SHA-256 of the lock file", func() {
+			Expect(os.WriteFile(path, []byte("{\"1.2.0\", []}.\n"), 0644)).To(Succeed())
+
+			digest, err := erlang.RebarLockDigest(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(digest).To(Equal("d81dc984d3c90a7077809075ac68322f4ea2da146789ca168a6e51f92dcc4b69"))
+		})
+
+		it("returns an empty digest when the lock file does not exist", func() {
+			digest, err := erlang.RebarLockDigest(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(digest).To(BeEmpty())
+		})
+	})
+
+	context("failure cases", func() {
+		it("reports malformed lock files", func() {
+			Expect(os.WriteFile(path, []byte(`{"1.2.0", [`), 0644)).To(Succeed())
+
+			_, err := erlang.ParseRebarLock(path)
+			Expect(err).To(MatchError(ContainSubstring("failed to parse")))
+		})
+
+		it("reports unsupported dependency sources", func() {
+			Expect(os.WriteFile(path, []byte(`{"1.2.0", [{<<"x">>,{hg,"url",{ref,"1"}},0}]}.`), 0644)).To(Succeed())
+
+			_, err := erlang.ParseRebarLock(path)
+			Expect(err).To(MatchError(ContainSubstring("dependency x has unsupported source hg")))
+		})
+
+		it("reports pkg sources without a package name", func() {
+			Expect(os.WriteFile(path, []byte(`{"1.2.0",[{<<"a">>,{pkg},0}]}.`), 0644)).To(Succeed())
+
+			_, err := erlang.ParseRebarLock(path)
+			Expect(err).To(MatchError(ContainSubstring("dependency a has no package name")))
+		})
+	})
+}
@@ -0,0 +1,187 @@
+package erlang
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+)
+
+const (
+	ReleaseLayerName = "release"
+
+	DefaultRebar3Profile = "prod"
+)
+
+//go:generate faux --interface Executable --output fakes/executable.go
+type Executable interface {
+	Execute(execution pexec.Execution) (err error)
+}
+
+// RelxRelease is a release declared in the relx section of rebar.config.
+type RelxRelease struct {
+	Name    string
+	Version string
+}
+
+// RelxConfig lists the releases rebar3 can build for a profile.
+type RelxConfig struct {
+	Releases []RelxRelease
+
+	// Source names the file the releases were declared in.
+	Source string
+
+	// Default is the name given by default_release, if any.
+	Default string
+}
+
+// ParseRelxConfig reads the relx section of the rebar.config at path, merged
+// with the relx section of profile, and reports whether there is one at all.
+// Releases declared by the profile replace top-level releases of the same
+// name. A missing rebar.config has no relx section.
+func ParseRelxConfig(path, profile string) (RelxConfig, bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return RelxConfig{}, false, nil
+		}
+		return RelxConfig{}, false, err
+	}
+
+	terms, err := ParseTerms(string(content))
+	if err != nil {
+		return RelxConfig{}, false, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	rebarConfig := List(terms)
+
+	var sections []List
+	if relx, ok := proplistValue(rebarConfig, "relx"); ok {
+		sections = append(sections, asList(relx))
+	}
+	if profiles, ok := proplistValue(rebarConfig, "profiles"); ok {
+		if settings, ok := proplistValue(asList(profiles), Atom(profile)); ok {
+			if relx, ok := proplistValue(asList(settings), "relx"); ok {
+				sections = append(sections, asList(relx))
+			}
+		}
+	}
+
+	config := RelxConfig{Source: filepath.Base(path)}
+	for _, section := range sections {
+		config.addOptions(section)
+	}
+
+	return config, len(sections) > 0, nil
+}
+
+// ParseRelxFile reads a standalone relx.config, as used by erlang.mk, whose
+// terms are the relx options themselves. A missing file reports false.
+func ParseRelxFile(path string) (RelxConfig, bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return RelxConfig{}, false, nil
+		}
+		return RelxConfig{}, false, err
+	}
+
+	terms, err := ParseTerms(string(content))
+	if err != nil {
+		return RelxConfig{}, false, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	config := RelxConfig{Source: filepath.Base(path)}
+	config.addOptions(List(terms))
+
+	return config, true, nil
+}
+
+func (c *RelxConfig) addOptions(options List) {
+	for _, option := range options {
+		tuple, ok := option.(Tuple)
+		if !ok || len(tuple) < 2 {
+			continue
+		}
+
+		switch tuple[0] {
+		case Atom("release"):
+			if release, ok := relxReleaseName(tuple[1]); ok {
+				c.add(release)
+			}
+
+		case Atom("default_release"):
+			if release, ok := relxReleaseName(tuple[1]); ok {
+				c.Default = release.Name
+			}
+		}
+	}
+}
+
+func (c *RelxConfig) add(release RelxRelease) {
+	for i, existing := range c.Releases {
+		if existing.Name == release.Name {
+			c.Releases[i] = release
+			return
+		}
+	}
+	c.Releases = append(c.Releases, release)
+}
+
+// relxReleaseName reads the {Name, Vsn} of a release declaration. Versions
+// computed by relx, such as {git, short}, are left empty.
+func relxReleaseName(term any) (RelxRelease, bool) {
+	tuple, ok := term.(Tuple)
+	if !ok || len(tuple) != 2 {
+		return RelxRelease{}, false
+	}
+
+	name, ok := tuple[0].(Atom)
+	if !ok {
+		return RelxRelease{}, false
+	}
+
+	return RelxRelease{Name: string(name), Version: termString(tuple[1])}, true
+}
+
+// Select returns the release called name. Without a name it returns the
+// default release, or the only release when there is just one.
+func (c RelxConfig) Select(name string) (RelxRelease, error) {
+	if len(c.Releases) == 0 {
+		return RelxRelease{}, fmt.Errorf("%s declares no relx releases", c.Source)
+	}
+
+	if name == "" {
+		name = c.Default
+	}
+
+	if name == "" {
+		if len(c.Releases) > 1 {
+			return RelxRelease{}, fmt.Errorf("%s declares several releases (%s): set BP_ERLANG_RELEASE_NAME to choose one", c.Source, c.names())
+		}
+		return c.Releases[0], nil
+	}
+
+	for _, release := range c.Releases {
+		if release.Name == name {
+			return release, nil
+		}
+	}
+
+	return RelxRelease{}, fmt.Errorf("%s declares no release %q (available: %s)", c.Source, name, c.names())
+}
+
+func (c RelxConfig) names() string {
+	var names []string
+	for _, release := range c.Releases {
+		names = append(names, release.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func asList(term any) List {
+	list, _ := term.(List)
+	return list
+}
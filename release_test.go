@@ -0,0 +1,179 @@
+package erlang_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SnakeDoc/erlang-cnb"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testRelease(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		path string
+	)
+
+	it.Before(func() {
+		path = filepath.Join(t.TempDir(), "rebar.config")
+	})
+
+	context("ParseRelxConfig", func() {
+		it("reads the releases from the relx section", func() {
+			Expect(os.WriteFile(path, []byte(`
+{erl_opts, [debug_info]}.
+{relx, [{release, {myapp, "0.1.0"}, [myapp, sasl]},
+        {release, {admin, "0.2.0"}, [admin]},
+        {dev_mode, true}]}.
+`), 0644)).To(Succeed())
+
+			config, ok, err := erlang.ParseRelxConfig(path, "prod")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(config.Releases).To(Equal([]erlang.RelxRelease{
+				{Name: "myapp", Version: "0.1.0"},
+				{Name: "admin", Version: "0.2.0"},
+			}))
+			Expect(config.Source).To(Equal("rebar.config"))
+		})
+
+		it("merges the relx section of the profile", func() {
+			Expect(os.WriteFile(path, []byte(`
+{relx, [{release, {myapp, "0.1.0"}, [myapp]}]}.
+{profiles, [{prod, [{relx, [{release, {myapp, "0.1.0-prod"}, [myapp]},
+                            {default_release, {myapp, "0.1.0-prod"}}]}]},
+            {test, [{relx, [{release, {other, "1"}, [other]}]}]}]}.
+`), 0644)).To(Succeed())
+
+			config, ok, err := erlang.ParseRelxConfig(path, "prod")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(config.Releases).To(Equal([]erlang.RelxRelease{{Name: "myapp", Version: "0.1.0-prod"}}))
+			Expect(config.Default).To(Equal("myapp"))
+		})
+
+		it("finds a relx section declared only in the profile", func() {
+			Expect(os.WriteFile(path, []byte(`
+{profiles, [{prod, [{relx, [{release, {myapp, {git, short}}, [myapp]}]}]}]}.
+`), 0644)).To(Succeed())
+
+			config, ok, err := erlang.ParseRelxConfig(path, "prod")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(config.Releases).To(Equal([]erlang.RelxRelease{{Name: "myapp"}}))
+		})
+
+		it("reports when there is no relx section", func() {
+			Expect(os.WriteFile(path, []byte("{deps, []}."), 0644)).To(Succeed())
+
+			_, ok, err := erlang.ParseRelxConfig(path, "prod")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+
+		it("reports when there is no rebar.config", func() {
+			_, ok, err := erlang.ParseRelxConfig(path, "prod")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+
+		context("when rebar.config is malformed", func() {
+			it("returns an error", func() {
+				Expect(os.WriteFile(path, []byte("{relx, ["), 0644)).To(Succeed())
+
+				_, _, err := erlang.ParseRelxConfig(path, "prod")
+				Expect(err).To(MatchError(ContainSubstring("failed to parse " + path)))
+			})
+		})
+	})
+
+	context("ParseRelxFile", func() {
+		it.Before(func() {
+			path = filepath.Join(filepath.Dir(path), "relx.config")
+		})
+
+		it("reads the releases from a standalone relx.config", func() {
+			Expect(os.WriteFile(path, []byte(`
+{release, {hello, "1.0.0"}, [hello, sasl]}.
+{extended_start_script, true}.
+{sys_config, "config/sys.config"}.
+`), 0644)).To(Succeed())
+
+			config, ok, err := erlang.ParseRelxFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(config.Releases).To(Equal([]erlang.RelxRelease{{Name: "hello", Version: "1.0.0"}}))
+			Expect(config.Source).To(Equal("relx.config"))
+		})
+
+		it("reports when there is no relx.config", func() {
+			_, ok, err := erlang.ParseRelxFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+
+		context("when relx.config is malformed", func() {
+			it("returns an error", func() {
+				Expect(os.WriteFile(path, []byte("{release, "), 0644)).To(Succeed())
+
+				_, _, err := erlang.ParseRelxFile(path)
+				Expect(err).To(MatchError(ContainSubstring("failed to parse " + path)))
+			})
+		})
+	})
+
+	context("Select", func() {
+		var config erlang.RelxConfig
+
+		it.Before(func() {
+			config = erlang.RelxConfig{
+				Source: "rebar.config",
+				Releases: []erlang.RelxRelease{
+					{Name: "myapp", Version: "0.1.0"},
+					{Name: "admin", Version: "0.2.0"},
+				},
+			}
+		})
+
+		it("selects the named release", func() {
+			release, err := config.Select("admin")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(release).To(Equal(erlang.RelxRelease{Name: "admin", Version: "0.2.0"}))
+		})
+
+		it("selects the default release", func() {
+			config.Default = "admin"
+
+			release, err := config.Select("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(release.Name).To(Equal("admin"))
+		})
+
+		it("selects the only release", func() {
+			config.Releases = config.Releases[:1]
+
+			release, err := config.Select("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(release.Name).To(Equal("myapp"))
+		})
+
+		it("requires a name when there are several releases", func() {
+			_, err := config.Select("")
+			Expect(err).To(MatchError("rebar.config declares several releases (myapp, admin): set BP_ERLANG_RELEASE_NAME to choose one"))
+		})
+
+		it("rejects an unknown name", func() {
+			_, err := config.Select("missing")
+			Expect(err).To(MatchError(`rebar.config declares no release "missing" (available: myapp, admin)`))
+		})
+
+		it("rejects a relx section without releases", func() {
+			_, err := erlang.RelxConfig{Source: "rebar.config"}.Select("")
+			Expect(err).To(MatchError("rebar.config declares no relx releases"))
+		})
+	})
+}
@@ -0,0 +1,98 @@
+package erlang
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// DefaultSourceDateEpoch is the timestamp layer contents are normalised to
+// when SOURCE_DATE_EPOCH is not set. It matches the timestamp the lifecycle
+// gives exported layers.
+var DefaultSourceDateEpoch = time.Date(1980, time.January, 1, 0, 0, 1, 0, time.UTC)
+
+// SourceDateEpoch returns the time given by SOURCE_DATE_EPOCH, in seconds
+// since the Unix epoch, and whether it was set.
+func SourceDateEpoch() (time.Time, bool, error) {
+	value := os.Getenv("SOURCE_DATE_EPOCH")
+	if value == "" {
+		return DefaultSourceDateEpoch, false, nil
+	}
+
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return time.Time{}, false, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: expected a non-negative number of seconds", value)
+	}
+
+	return time.Unix(seconds, 0).UTC(), true, nil
+}
+
+// NormalizeLayer makes the metadata of everything under layerPath independent
+// of when and by whom it was extracted: modification times are set to epoch,
+// directories and executables get mode 0755, other files 0644, and every
+// entry is owned by the current user and group.
+func NormalizeLayer(layerPath string, epoch time.Time) error {
+	uid, gid := os.Getuid(), os.Getgid()
+	times := []unix.Timeval{unix.NsecToTimeval(epoch.UnixNano()), unix.NsecToTimeval(epoch.UnixNano())}
+
+	// directories are visited before their contents, so their times are set
+	// on the way back out once nothing below them changes any more
+	var dirs []string
+
+	err := filepath.WalkDir(layerPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		err = os.Lchown(path, uid, gid)
+		if err != nil {
+			return fmt.Errorf("failed to change owner of %s: %w", path, err)
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() || d.Type().IsRegular() {
+			mode := os.FileMode(0644)
+			if d.IsDir() || info.Mode().Perm()&0111 != 0 {
+				mode = 0755
+			}
+
+			err = os.Chmod(path, mode)
+			if err != nil {
+				return fmt.Errorf("failed to change mode of %s: %w", path, err)
+			}
+		}
+
+		if d.IsDir() {
+			dirs = append(dirs, path)
+			return nil
+		}
+
+		err = unix.Lutimes(path, times)
+		if err != nil {
+			return fmt.Errorf("failed to set modification time of %s: %w", path, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to normalize %s: %w", layerPath, err)
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		err = os.Chtimes(dirs[i], epoch, epoch)
+		if err != nil {
+			return fmt.Errorf("failed to normalize %s: failed to set modification time of %s: %w", layerPath, dirs[i], err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package erlang_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SnakeDoc/erlang-cnb"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testReproducible(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("SourceDateEpoch", func() {
+		it.After(func() {
+			Expect(os.Unsetenv("SOURCE_DATE_EPOCH")).To(Succeed())
+		})
+
+		it("defaults to the lifecycle timestamp", func() {
+			epoch, set, err := erlang.SourceDateEpoch()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(set).To(BeFalse())
+			Expect(epoch).To(Equal(time.Date(1980, time.January, 1, 0, 0, 1, 0, time.UTC)))
+		})
+
+		it("reads SOURCE_DATE_EPOCH", func() {
+			Expect(os.Setenv("SOURCE_DATE_EPOCH", "1700000000")).To(Succeed())
+
+			epoch, set, err := erlang.SourceDateEpoch()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(set).To(BeTrue())
+			Expect(epoch).To(Equal(time.Unix(1700000000, 0).UTC()))
+		})
+
+		it("rejects values that are not seconds", func() {
+			Expect(os.Setenv("SOURCE_DATE_EPOCH", "yesterday")).To(Succeed())
+
+			_, _, err := erlang.SourceDateEpoch()
+			Expect(err).To(MatchError(`invalid SOURCE_DATE_EPOCH "yesterday": expected a non-negative number of seconds`))
+		})
+	})
+
+	context("NormalizeLayer", func() {
+		var (
+			layerPath string
+			epoch     time.Time
+		)
+
+		it.Before(func() {
+			layerPath = t.TempDir()
+			epoch = time.Unix(1700000000, 0)
+
+			Expect(os.MkdirAll(filepath.Join(layerPath, "bin"), 0700)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(layerPath, "lib", "stdlib-7.1", "ebin"), 0775)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(layerPath, "bin", "erl"), []byte("erl"), 0700)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(layerPath, "lib", "stdlib-7.1", "ebin", "lists.beam"), []byte("beam"), 0600)).To(Succeed())
+			Expect(os.Symlink("erl", filepath.Join(layerPath, "bin", "erlang"))).To(Succeed())
+		})
+
+		it("normalises modification times and modes", func() {
+			Expect(erlang.NormalizeLayer(layerPath, epoch)).To(Succeed())
+
+			for path, mode := range map[string]os.FileMode{
+				"":                               os.ModeDir | 0755,
+				"bin":                            os.ModeDir | 0755,
+				"bin/erl":                        0755,
+				"lib/stdlib-7.1/ebin":            os.ModeDir | 0755,
+				"lib/stdlib-7.1/ebin/lists.beam": 0644,
+			} {
+				info, err := os.Lstat(filepath.Join(layerPath, path))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(info.Mode()).To(Equal(mode), path)
+				Expect(info.ModTime()).To(BeTemporally("==", epoch), path)
+			}
+
+			link, err := os.Lstat(filepath.Join(layerPath, "bin", "erlang"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(link.Mode() & os.ModeSymlink).NotTo(BeZero())
+			Expect(link.ModTime()).To(BeTemporally("==", epoch))
+		})
+
+		context("when the layer does not exist", func() {
+			it("returns an error", func() {
+				err := erlang.NormalizeLayer(filepath.Join(layerPath, "missing"), epoch)
+				Expect(err).To(MatchError(ContainSubstring("failed to normalize")))
+			})
+		})
+	})
+}
@@ -6,16 +6,25 @@ import (
 	erlang "github.com/SnakeDoc/erlang-cnb"
 	"github.com/paketo-buildpacks/packit/v2"
 	"github.com/paketo-buildpacks/packit/v2/chronos"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
 	"github.com/paketo-buildpacks/packit/v2/scribe"
+	"github.com/paketo-buildpacks/packit/v2/servicebindings"
 )
 
 func main() {
 	ToolVersionsParser := erlang.NewToolVersionsParser()
 	installer := erlang.NewErlangInstaller()
+	rebar3Installer := erlang.NewRebar3Downloader()
+	rebar3 := pexec.NewExecutable("rebar3")
+	gnuMake := pexec.NewExecutable("make")
+	erl := pexec.NewExecutable("erl")
+	erlc := pexec.NewExecutable("erlc")
+	buildsIndex := erlang.NewBuildsIndex()
+	bindingResolver := servicebindings.NewResolver()
 	logEmitter := scribe.NewEmitter(os.Stdout).WithLevel(os.Getenv("BP_LOG_LEVEL"))
 
 	packit.Run(
 		erlang.Detect(ToolVersionsParser),
-		erlang.Build(installer, logEmitter, chronos.DefaultClock),
+		erlang.Build(installer, rebar3Installer, rebar3, gnuMake, erl, erlc, buildsIndex, bindingResolver, logEmitter, chronos.DefaultClock),
 	)
 }
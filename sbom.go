@@ -0,0 +1,160 @@
+package erlang
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/anchore/syft/syft/cpe"
+	"github.com/anchore/syft/syft/pkg"
+	syftsbom "github.com/anchore/syft/syft/sbom"
+	"github.com/anchore/syft/syft/source"
+	"github.com/package-url/packageurl-go"
+	"github.com/paketo-buildpacks/packit/v2/sbom"
+)
+
+// OTPRelease identifies the OTP build installed into the erlang layer.
+type OTPRelease struct {
+	Version string
+	URL     string
+	SHA256  string
+}
+
+// GenerateSBOM describes the OTP release installed at path, together with the
+// bundled applications it contains.
+func GenerateSBOM(path string, release OTPRelease, apps []OTPApp) (sbom.SBOM, error) {
+	otpCPE, err := cpe.New(fmt.Sprintf("cpe:2.3:a:erlang:erlang\\/otp:%s:*:*:*:*:*:*:*", release.Version), cpe.DeclaredSource)
+	if err != nil {
+		return sbom.SBOM{}, fmt.Errorf("failed to build CPE for OTP %s: %w", release.Version, err)
+	}
+
+	qualifiers := packageurl.QualifiersFromMap(map[string]string{
+		"download_url": release.URL,
+		"checksum":     "sha256:" + release.SHA256,
+	})
+
+	packages := []pkg.Package{
+		{
+			Name:     "erlang-otp",
+			Version:  release.Version,
+			Type:     pkg.ErlangOTPPkg,
+			Language: pkg.Erlang,
+			Licenses: pkg.NewLicenseSet(pkg.NewLicense("Apache-2.0")),
+			CPEs:     []cpe.CPE{otpCPE},
+			PURL:     packageurl.NewPackageURL(packageurl.TypeGeneric, "erlang", "otp", release.Version, qualifiers, "").ToString(),
+		},
+	}
+
+	sort.Slice(apps, func(i, j int) bool { return apps[i].Name < apps[j].Name })
+	for _, app := range apps {
+		packages = append(packages, pkg.Package{
+			Name:     app.Name,
+			Version:  app.Version,
+			Type:     pkg.ErlangOTPPkg,
+			Language: pkg.Erlang,
+			Licenses: pkg.NewLicenseSet(pkg.NewLicense("Apache-2.0")),
+			PURL:     packageurl.NewPackageURL(packageurl.TypeOTP, "", app.Name, app.Version, nil, "").ToString(),
+		})
+	}
+
+	for i := range packages {
+		packages[i].SetID()
+	}
+
+	return sbom.NewSBOM(syftsbom.SBOM{
+		Artifacts: syftsbom.Artifacts{
+			Packages: pkg.NewCollection(packages...),
+		},
+		Source: source.Description{
+			Metadata: source.DirectoryMetadata{
+				Path: path,
+			},
+		},
+	}), nil
+}
+
+// appsIn returns the applications in apps whose names are in keep, or all of
+// them when keep is nil.
+func appsIn(apps map[string]OTPApp, keep map[string]bool) []OTPApp {
+	var list []OTPApp
+	for name, app := range apps {
+		if keep == nil || keep[name] {
+			list = append(list, app)
+		}
+	}
+	return list
+}
+
+// GenerateDependencySBOM describes the application dependencies pinned by the
+// lock file at path. Dependencies from a mix.lock are recorded as Elixir
+// packages, anything else as Erlang packages.
+func GenerateDependencySBOM(path string, deps []LockedDependency) (sbom.SBOM, error) {
+	isMix := filepath.Base(path) == "mix.lock"
+
+	var packages []pkg.Package
+	for _, dep := range deps {
+		p := pkg.Package{
+			Name:     dep.Name,
+			Version:  dep.Version,
+			Language: pkg.Erlang,
+		}
+		if isMix {
+			p.Language = pkg.Elixir
+		}
+
+		switch dep.Source {
+		case "hex":
+			p.Type = pkg.HexPkg
+			// an aliased dependency is published under its package name
+			name := dep.Package
+			if name == "" {
+				name = dep.Name
+			}
+			p.PURL = packageurl.NewPackageURL(packageurl.TypeHex, "", name, dep.Version, nil, "").ToString()
+			if isMix {
+				p.Metadata = pkg.ElixirMixLockEntry{
+					Name:       dep.Name,
+					Version:    dep.Version,
+					PkgHash:    dep.InnerSHA256,
+					PkgHashExt: dep.SHA256,
+				}
+			} else {
+				p.Metadata = pkg.ErlangRebarLockEntry{
+					Name:       dep.Name,
+					Version:    dep.Version,
+					PkgHash:    dep.InnerSHA256,
+					PkgHashExt: dep.SHA256,
+				}
+			}
+
+		case "git":
+			qualifiers := packageurl.QualifiersFromMap(map[string]string{
+				"vcs_url": fmt.Sprintf("git+%s@%s", dep.URL, dep.Ref),
+			})
+			p.PURL = packageurl.NewPackageURL(packageurl.TypeGeneric, "", dep.Name, dep.Ref, qualifiers, "").ToString()
+
+		case "path":
+			qualifiers := packageurl.QualifiersFromMap(map[string]string{
+				"path": dep.URL,
+			})
+			p.PURL = packageurl.NewPackageURL(packageurl.TypeGeneric, "", dep.Name, dep.Version, qualifiers, "").ToString()
+
+		default:
+			return sbom.SBOM{}, fmt.Errorf("unsupported source %q for dependency %s", dep.Source, dep.Name)
+		}
+
+		p.SetID()
+		packages = append(packages, p)
+	}
+
+	return sbom.NewSBOM(syftsbom.SBOM{
+		Artifacts: syftsbom.Artifacts{
+			Packages: pkg.NewCollection(packages...),
+		},
+		Source: source.Description{
+			Metadata: source.FileMetadata{
+				Path: path,
+			},
+		},
+	}), nil
+}
@@ -0,0 +1,42 @@
+package erlang_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/SnakeDoc/erlang-cnb"
+	"github.com/paketo-buildpacks/packit/v2/sbom"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testSBOM(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	it("lists OTP and each bundled application", func() {
+		bom, err := erlang.GenerateSBOM("/layers/erlang", erlang.OTPRelease{
+			Version: "28.1.1",
+			URL:     "https://builds.hex.pm/builds/otp/amd64/ubuntu-22.04/OTP-28.1.1.tar.gz",
+			SHA256:  "abc123",
+		}, []erlang.OTPApp{
+			{Name: "stdlib", Version: "7.1"},
+			{Name: "kernel", Version: "10.4"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		formatter, err := bom.InFormats(sbom.SyftFormat)
+		Expect(err).NotTo(HaveOccurred())
+
+		content, err := io.ReadAll(formatter.Formats()[0].Content)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(string(content)).To(ContainSubstring(`"name":"erlang-otp"`))
+		Expect(string(content)).To(ContainSubstring(`"version":"28.1.1"`))
+		Expect(string(content)).To(ContainSubstring(`cpe:2.3:a:erlang:erlang\\/otp:28.1.1`))
+		Expect(string(content)).To(ContainSubstring(`"purl":"pkg:otp/kernel@10.4"`))
+		Expect(string(content)).To(ContainSubstring(`"purl":"pkg:otp/stdlib@7.1"`))
+		Expect(string(content)).To(ContainSubstring(`checksum=sha256:abc123`))
+		Expect(string(content)).To(ContainSubstring(`"path":"/layers/erlang"`))
+	})
+}
@@ -0,0 +1,159 @@
+// Command update-advisories regenerates advisories.toml from the security
+// advisories published on the erlang/otp GitHub repository.
+//
+//	go run ./scripts/update-advisories --output advisories.toml
+//
+// Set GITHUB_TOKEN to avoid the anonymous API rate limit.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	erlang "github.com/SnakeDoc/erlang-cnb"
+)
+
+const advisoriesURL = "https://api.github.com/repos/erlang/otp/security-advisories?state=published&per_page=100"
+
+const header = `# Known vulnerabilities in Erlang/OTP releases.
+#
+# Each advisory lists the first fixed patch release of every maintenance line
+# that received a fix. A version is affected when its line has a fixed
+# release greater than it, or when it belongs to a line older than every
+# fixed line. Regenerate with ` + "`go run ./scripts/update-advisories`" + `.
+
+`
+
+// otpVersion matches OTP release numbers such as "OTP-27.3.3", but not
+// application versions such as "ssh-5.2.10" or "ssh 5.2.10".
+var otpVersion = regexp.MustCompile(`\bOTP-(\d+(?:\.\d+)+)`)
+
+// nextLink matches the next page in a GitHub Link header.
+var nextLink = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+type githubAdvisory struct {
+	GHSAID          string `json:"ghsa_id"`
+	CVEID           string `json:"cve_id"`
+	Summary         string `json:"summary"`
+	Vulnerabilities []struct {
+		PatchedVersions string `json:"patched_versions"`
+	} `json:"vulnerabilities"`
+}
+
+func main() {
+	output := flag.String("output", "advisories.toml", "path of the advisory table to write")
+	flag.Parse()
+
+	var advisories []githubAdvisory
+	url := advisoriesURL
+	for url != "" {
+		page, next, err := fetchAdvisories(url)
+		if err != nil {
+			log.Fatal(err)
+		}
+		advisories = append(advisories, page...)
+		url = next
+	}
+
+	var db erlang.AdvisoryDatabase
+	for _, advisory := range advisories {
+		id := advisory.CVEID
+		if id == "" {
+			id = advisory.GHSAID
+		}
+
+		fixed := map[string]bool{}
+		for _, vulnerability := range advisory.Vulnerabilities {
+			for _, match := range otpVersion.FindAllStringSubmatch(vulnerability.PatchedVersions, -1) {
+				fixed[match[1]] = true
+			}
+		}
+
+		if len(fixed) == 0 {
+			log.Printf("skipping %s: no fixed OTP versions listed", id)
+			continue
+		}
+
+		entry := erlang.Advisory{ID: id, Summary: advisory.Summary}
+		for version := range fixed {
+			entry.Fixed = append(entry.Fixed, version)
+		}
+		sort.Slice(entry.Fixed, func(i, j int) bool { return lessVersion(entry.Fixed[i], entry.Fixed[j]) })
+
+		db.Advisories = append(db.Advisories, entry)
+	}
+
+	sort.Slice(db.Advisories, func(i, j int) bool { return db.Advisories[i].ID > db.Advisories[j].ID })
+
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	err := toml.NewEncoder(&buf).Encode(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = os.WriteFile(*output, buf.Bytes(), 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Wrote %d advisories to %s\n", len(db.Advisories), *output)
+}
+
+// fetchAdvisories fetches one page of advisories and returns the URL of the
+// next page, which is empty on the last one.
+func fetchAdvisories(url string) ([]githubAdvisory, string, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch advisories: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("failed to fetch advisories from %s: received status code %d", url, resp.StatusCode)
+	}
+
+	var advisories []githubAdvisory
+	err = json.NewDecoder(resp.Body).Decode(&advisories)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode advisories: %w", err)
+	}
+
+	var next string
+	if match := nextLink.FindStringSubmatch(resp.Header.Get("Link")); match != nil {
+		next = match[1]
+	}
+
+	return advisories, next, nil
+}
+
+func lessVersion(a, b string) bool {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, _ := strconv.Atoi(as[i])
+		bn, _ := strconv.Atoi(bs[i])
+		if an != bn {
+			return an < bn
+		}
+	}
+	return len(as) < len(bs)
+}
@@ -0,0 +1,111 @@
+package erlang
+
+import (
+	_ "embed"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+//go:embed support_calendar.toml
+var supportCalendarTOML string
+
+// MajorRelease records when an OTP major version was first released.
+type MajorRelease struct {
+	Major    int       `toml:"major"`
+	Released time.Time `toml:"released"`
+}
+
+// SupportCalendar is the table of OTP major releases shipped with the
+// buildpack, from which the support status of a version is derived.
+type SupportCalendar struct {
+	SupportedMajors int            `toml:"supported-majors"`
+	Releases        []MajorRelease `toml:"releases"`
+}
+
+// SupportStatus describes whether a version is still maintained upstream.
+type SupportStatus struct {
+	Major     int
+	Supported bool
+
+	// EndOfSupport and ReplacedBy are set for unsupported majors that appear
+	// in the calendar: the date support ended and the major whose release
+	// ended it.
+	EndOfSupport time.Time
+	ReplacedBy   int
+}
+
+// LoadSupportCalendar parses the support calendar embedded in the buildpack.
+func LoadSupportCalendar() (SupportCalendar, error) {
+	return ParseSupportCalendar(supportCalendarTOML)
+}
+
+func ParseSupportCalendar(content string) (SupportCalendar, error) {
+	var calendar SupportCalendar
+	_, err := toml.Decode(content, &calendar)
+	if err != nil {
+		return SupportCalendar{}, fmt.Errorf("failed to parse support calendar: %w", err)
+	}
+
+	if calendar.SupportedMajors < 1 {
+		return SupportCalendar{}, fmt.Errorf("support calendar must support at least one major version")
+	}
+
+	sort.Slice(calendar.Releases, func(i, j int) bool {
+		return calendar.Releases[i].Major < calendar.Releases[j].Major
+	})
+
+	return calendar, nil
+}
+
+// Status returns the support status of version on the given date. Majors newer
+// than any in the calendar are assumed to be supported; majors older than any
+// in it are not. Versions that are not release numbers report ok=false.
+func (c SupportCalendar) Status(version string, now time.Time) (status SupportStatus, ok bool) {
+	ver, err := parseVersion(parseOTPVersion(version))
+	if err != nil {
+		return SupportStatus{}, false
+	}
+	status.Major = ver[0]
+
+	// the majors released by now, newest first
+	var released []MajorRelease
+	for i := len(c.Releases) - 1; i >= 0; i-- {
+		if !c.Releases[i].Released.After(now) {
+			released = append(released, c.Releases[i])
+		}
+	}
+
+	if len(released) == 0 || status.Major > released[0].Major {
+		status.Supported = true
+		return status, true
+	}
+
+	for i, release := range released {
+		if release.Major > status.Major {
+			continue
+		}
+
+		if i < c.SupportedMajors {
+			status.Supported = true
+			return status, true
+		}
+
+		// support ended when the SupportedMajors-th newer major shipped
+		successor := released[i-c.SupportedMajors]
+		status.EndOfSupport = successor.Released
+		status.ReplacedBy = successor.Major
+		return status, true
+	}
+
+	return status, true
+}
+
+// IsSupported reports whether version is supported on the given date.
+// Versions that are not release numbers are treated as supported.
+func (c SupportCalendar) IsSupported(version string, now time.Time) bool {
+	status, ok := c.Status(version, now)
+	return !ok || status.Supported
+}
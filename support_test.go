@@ -0,0 +1,117 @@
+package erlang_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SnakeDoc/erlang-cnb"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testSupport(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		calendar erlang.SupportCalendar
+		now      time.Time
+	)
+
+	it.Before(func() {
+		var err error
+		calendar, err = erlang.ParseSupportCalendar(`
+			supported-majors = 3
+
+			[[releases]]
+			major = 26
+			released = 2023-05-16
+
+			[[releases]]
+			major = 24
+			released = 2021-05-12
+
+			[[releases]]
+			major = 25
+			released = 2022-05-18
+
+			[[releases]]
+			major = 27
+			released = 2024-05-20
+
+			[[releases]]
+			major = 28
+			released = 2025-05-21
+		`)
+		Expect(err).NotTo(HaveOccurred())
+
+		now = time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)
+	})
+
+	context("Status", func() {
+		it("supports the three newest released majors", func() {
+			for _, version := range []string{"26.2.5", "27.3", "OTP-28.1.1"} {
+				status, ok := calendar.Status(version, now)
+				Expect(ok).To(BeTrue())
+				Expect(status.Supported).To(BeTrue(), version)
+			}
+		})
+
+		it("reports when and why support ended for older majors", func() {
+			status, ok := calendar.Status("25.3.2.12", now)
+			Expect(ok).To(BeTrue())
+			Expect(status.Supported).To(BeFalse())
+			Expect(status.Major).To(Equal(25))
+			Expect(status.ReplacedBy).To(Equal(28))
+			Expect(status.EndOfSupport.Format(time.DateOnly)).To(Equal("2025-05-21"))
+
+			status, _ = calendar.Status("24.3", now)
+			Expect(status.ReplacedBy).To(Equal(27))
+		})
+
+		it("only counts majors released by the given date", func() {
+			status, _ := calendar.Status("25.3", time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC))
+			Expect(status.Supported).To(BeTrue())
+		})
+
+		it("treats majors newer than the calendar as supported", func() {
+			status, ok := calendar.Status("29.0", now)
+			Expect(ok).To(BeTrue())
+			Expect(status.Supported).To(BeTrue())
+		})
+
+		it("treats majors older than the calendar as unsupported", func() {
+			status, ok := calendar.Status("21.3", now)
+			Expect(ok).To(BeTrue())
+			Expect(status.Supported).To(BeFalse())
+			Expect(status.ReplacedBy).To(Equal(0))
+		})
+
+		it("ignores versions that are not release numbers", func() {
+			_, ok := calendar.Status("master", now)
+			Expect(ok).To(BeFalse())
+			Expect(calendar.IsSupported("master", now)).To(BeTrue())
+		})
+	})
+
+	context("LoadSupportCalendar", func() {
+		it("parses the embedded calendar", func() {
+			calendar, err := erlang.LoadSupportCalendar()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(calendar.SupportedMajors).To(Equal(3))
+			Expect(calendar.Releases).NotTo(BeEmpty())
+		})
+	})
+
+	context("failure cases", func() {
+		it("rejects a calendar without supported majors", func() {
+			_, err := erlang.ParseSupportCalendar(`supported-majors = 0`)
+			Expect(err).To(MatchError("support calendar must support at least one major version"))
+		})
+
+		it("rejects malformed calendars", func() {
+			_, err := erlang.ParseSupportCalendar(`[[releases]`)
+			Expect(err).To(MatchError(ContainSubstring("failed to parse support calendar")))
+		})
+	})
+}
@@ -0,0 +1,261 @@
+package erlang
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	TestReportsLayerName = "test-reports"
+
+	EUnit      = "eunit"
+	CommonTest = "ct"
+)
+
+// maxReportedFailures caps the failed cases named in the build error; the
+// rest are only counted.
+const maxReportedFailures = 5
+
+var (
+	// ctFailureLine matches the cases rebar3 ct lists as failed, e.g.
+	// "%%% http_SUITE ==> get_root: FAILED".
+	ctFailureLine = regexp.MustCompile(`^%%% (\S+) ==> (\S+): FAILED`)
+
+	// eunitFailureLine matches the numbered entries under "Failures:" in the
+	// output of rebar3 eunit, e.g. "  1) http_tests:parse_test/0".
+	eunitFailureLine = regexp.MustCompile(`^\s+\d+\) (\S+)`)
+)
+
+// TestTasks parses BP_ERLANG_RUN_TESTS, a comma separated list of the rebar3
+// test tasks to run. Repeated tasks are only run once.
+func TestTasks(value string) ([]string, error) {
+	var tasks []string
+	seen := map[string]bool{}
+	for _, task := range strings.Split(value, ",") {
+		task = strings.TrimSpace(task)
+		if task == "" {
+			continue
+		}
+
+		if task != EUnit && task != CommonTest {
+			return nil, fmt.Errorf("unsupported test task %q in BP_ERLANG_RUN_TESTS: expected %s or %s", task, EUnit, CommonTest)
+		}
+
+		if !seen[task] {
+			seen[task] = true
+			tasks = append(tasks, task)
+		}
+	}
+
+	return tasks, nil
+}
+
+// TestTaskArgs returns the rebar3 arguments that run task. Common Test writes
+// its logs, and the XML of hooks such as cth_surefire, into reportsDir.
+func TestTaskArgs(task, reportsDir string) []string {
+	if task == CommonTest {
+		return []string{CommonTest, "--logdir", filepath.Join(reportsDir, CommonTest)}
+	}
+	return []string{task}
+}
+
+// CollectSurefireReports copies the surefire XML reports written by the
+// eunit_surefire reporter, TEST-*.xml in the app directory or under
+// _build/test, into reportsDir/<task>. It returns the number of reports
+// copied.
+func CollectSurefireReports(workingDir, reportsDir, task string) (int, error) {
+	var paths []string
+
+	matches, err := filepath.Glob(filepath.Join(workingDir, "TEST-*.xml"))
+	if err != nil {
+		return 0, err
+	}
+	paths = append(paths, matches...)
+
+	err = filepath.WalkDir(filepath.Join(workingDir, "_build", "test"), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		// compiled dependencies bring their own reports, if any
+		if entry.IsDir() && entry.Name() == "lib" {
+			return filepath.SkipDir
+		}
+
+		if entry.Type().IsRegular() && isSurefireReport(entry.Name()) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to look for test reports: %w", err)
+	}
+
+	if len(paths) == 0 {
+		return 0, nil
+	}
+
+	destination := filepath.Join(reportsDir, task)
+	err = os.MkdirAll(destination, os.ModePerm)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s: %w", destination, err)
+	}
+
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read test report: %w", err)
+		}
+
+		err = os.WriteFile(filepath.Join(destination, filepath.Base(path)), content, 0644)
+		if err != nil {
+			return 0, fmt.Errorf("failed to copy test report: %w", err)
+		}
+	}
+
+	return len(paths), nil
+}
+
+// FailedTestCases returns the sorted names of the failed and erroneous test
+// cases in the XML reports under reportsDir, as "<classname>:<name>". XML
+// files that cannot be parsed are not reports and are skipped.
+func FailedTestCases(reportsDir string) ([]string, error) {
+	var failed []string
+
+	err := filepath.WalkDir(reportsDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		if !entry.Type().IsRegular() || filepath.Ext(path) != ".xml" {
+			return nil
+		}
+
+		cases, err := failedReportCases(path)
+		if err != nil {
+			return nil
+		}
+		failed = append(failed, cases...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(failed)
+	return failed, nil
+}
+
+// FailedOutputCases returns the failed test cases listed in the output of a
+// rebar3 eunit or ct run, for runs that wrote no XML reports.
+func FailedOutputCases(output string) []string {
+	var failed []string
+	inFailures := false
+	for _, line := range strings.Split(output, "\n") {
+		if match := ctFailureLine.FindStringSubmatch(line); match != nil {
+			failed = append(failed, match[1]+":"+match[2])
+			continue
+		}
+
+		switch {
+		case strings.TrimSpace(line) == "Failures:":
+			inFailures = true
+		case inFailures && strings.HasPrefix(strings.TrimSpace(line), "Finished in"):
+			inFailures = false
+		case inFailures:
+			if match := eunitFailureLine.FindStringSubmatch(line); match != nil {
+				failed = append(failed, match[1])
+			}
+		}
+	}
+	return failed
+}
+
+// TestFailureSummary describes failed test cases in a single line.
+func TestFailureSummary(failed []string) string {
+	if len(failed) == 0 {
+		return "no failed cases reported"
+	}
+
+	noun := "cases"
+	if len(failed) == 1 {
+		noun = "case"
+	}
+
+	shown := failed
+	if len(shown) > maxReportedFailures {
+		shown = shown[:maxReportedFailures]
+	}
+
+	summary := fmt.Sprintf("%d failed %s: %s", len(failed), noun, strings.Join(shown, ", "))
+	if rest := len(failed) - len(shown); rest > 0 {
+		summary += fmt.Sprintf(" and %d more", rest)
+	}
+	return summary
+}
+
+type testCase struct {
+	ClassName string     `xml:"classname,attr"`
+	Name      string     `xml:"name,attr"`
+	Failures  []struct{} `xml:"failure"`
+	Errors    []struct{} `xml:"error"`
+}
+
+func failedReportCases(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var failed []string
+	decoder := xml.NewDecoder(file)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "testcase" {
+			continue
+		}
+
+		var c testCase
+		err = decoder.DecodeElement(&c, &start)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(c.Failures) > 0 || len(c.Errors) > 0 {
+			name := c.Name
+			if c.ClassName != "" {
+				name = c.ClassName + ":" + c.Name
+			}
+			failed = append(failed, name)
+		}
+	}
+
+	return failed, nil
+}
+
+func isSurefireReport(name string) bool {
+	return strings.HasPrefix(name, "TEST-") && strings.HasSuffix(name, ".xml")
+}
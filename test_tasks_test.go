@@ -0,0 +1,122 @@
+package erlang_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SnakeDoc/erlang-cnb"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testTestTasks(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("TestTasks", func() {
+		it("parses the tasks in order, once each", func() {
+			tasks, err := erlang.TestTasks("eunit, ct,eunit,")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tasks).To(Equal([]string{"eunit", "ct"}))
+		})
+
+		context("when a task is not supported", func() {
+			it("returns an error", func() {
+				_, err := erlang.TestTasks("eunit,proper")
+				Expect(err).To(MatchError(`unsupported test task "proper" in BP_ERLANG_RUN_TESTS: expected eunit or ct`))
+			})
+		})
+	})
+
+	context("TestTaskArgs", func() {
+		it("writes the Common Test logs into the reports directory", func() {
+			Expect(erlang.TestTaskArgs("ct", "/reports")).To(Equal([]string{"ct", "--logdir", "/reports/ct"}))
+			Expect(erlang.TestTaskArgs("eunit", "/reports")).To(Equal([]string{"eunit"}))
+		})
+	})
+
+	context("CollectSurefireReports", func() {
+		var workingDir, reportsDir string
+
+		it.Before(func() {
+			workingDir = t.TempDir()
+			reportsDir = t.TempDir()
+		})
+
+		it("copies the surefire reports of the app", func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "TEST-http_tests.xml"), []byte("<testsuite/>"), 0644)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(workingDir, "_build", "test", "reports"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "_build", "test", "reports", "TEST-json_tests.xml"), []byte("<testsuite/>"), 0644)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(workingDir, "_build", "test", "lib", "cowboy"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "_build", "test", "lib", "cowboy", "TEST-cowboy.xml"), []byte("<testsuite/>"), 0644)).To(Succeed())
+
+			count, err := erlang.CollectSurefireReports(workingDir, reportsDir, "eunit")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(count).To(Equal(2))
+
+			Expect(filepath.Join(reportsDir, "eunit", "TEST-http_tests.xml")).To(BeARegularFile())
+			Expect(filepath.Join(reportsDir, "eunit", "TEST-json_tests.xml")).To(BeARegularFile())
+			Expect(filepath.Join(reportsDir, "eunit", "TEST-cowboy.xml")).NotTo(BeAnExistingFile())
+		})
+
+		it("copies nothing when there are no reports", func() {
+			count, err := erlang.CollectSurefireReports(workingDir, reportsDir, "eunit")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(count).To(Equal(0))
+			Expect(filepath.Join(reportsDir, "eunit")).NotTo(BeAnExistingFile())
+		})
+	})
+
+	context("FailedTestCases", func() {
+		it("lists the failed and erroneous cases of the reports", func() {
+			reportsDir := t.TempDir()
+			Expect(os.MkdirAll(filepath.Join(reportsDir, "ct"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(reportsDir, "TEST-http_tests.xml"), []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="http_tests" tests="3" failures="1" errors="1">
+  <testcase classname="http_tests" name="parse_test/0"><failure type="assertEqual">bad</failure></testcase>
+  <testcase classname="http_tests" name="get_test/0"/>
+  <testcase classname="http_tests" name="post_test/0"><error type="badarg">crash</error></testcase>
+</testsuite>`), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(reportsDir, "ct", "junit_report.xml"), []byte(`<testsuites>
+  <testsuite name="api_SUITE"><testcase classname="api_SUITE" name="get_root"><failure/></testcase></testsuite>
+</testsuites>`), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(reportsDir, "ct", "broken.xml"), []byte(`<testsuite`), 0644)).To(Succeed())
+
+			failed, err := erlang.FailedTestCases(reportsDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(failed).To(Equal([]string{"api_SUITE:get_root", "http_tests:parse_test/0", "http_tests:post_test/0"}))
+		})
+	})
+
+	context("FailedOutputCases", func() {
+		it("lists the cases rebar3 reports as failed", func() {
+			Expect(erlang.FailedOutputCases(`===> Performing EUnit tests...
+F.
+
+Failures:
+
+  1) http_tests:parse_test/0
+     Failure/Error: ?assertEqual(1, 2)
+       expected: 1
+            got: 2
+     %% http_tests.erl:12:in ` + "`" + `-parse_test/0-fun-0-` + "`" + `
+
+Finished in 0.010 seconds
+2 tests, 1 failures
+`)).To(Equal([]string{"http_tests:parse_test/0"}))
+
+			Expect(erlang.FailedOutputCases(`%%% api_SUITE ==> get_root: FAILED
+%%% api_SUITE ==> {error,{test_case_failed,bad}}
+`)).To(Equal([]string{"api_SUITE:get_root"}))
+		})
+	})
+
+	context("TestFailureSummary", func() {
+		it("names the first failed cases and counts the rest", func() {
+			Expect(erlang.TestFailureSummary([]string{"a"})).To(Equal("1 failed case: a"))
+			Expect(erlang.TestFailureSummary([]string{"a", "b", "c", "d", "e", "f", "g"})).To(Equal("7 failed cases: a, b, c, d, e and 2 more"))
+			Expect(erlang.TestFailureSummary(nil)).To(Equal("no failed cases reported"))
+		})
+	})
+}
@@ -12,7 +12,19 @@ func NewToolVersionsParser() ToolVersionsParser {
 	return ToolVersionsParser{}
 }
 
+// ParseVersion returns the erlang version listed in the .tool-versions file
+// at path, or an empty string when there is none.
 func (p ToolVersionsParser) ParseVersion(path string) (string, error) {
+	return parseToolVersion(path, "erlang")
+}
+
+// ParseRebar3Version returns the rebar version listed in the .tool-versions
+// file at path, or an empty string when there is none.
+func (p ToolVersionsParser) ParseRebar3Version(path string) (string, error) {
+	return parseToolVersion(path, "rebar")
+}
+
+func parseToolVersion(path, tool string) (string, error) {
 	file, err := os.Open(path)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -32,7 +44,7 @@ func (p ToolVersionsParser) ParseVersion(path string) (string, error) {
 		}
 
 		parts := strings.Fields(line)
-		if len(parts) >= 2 && parts[0] == "erlang" {
+		if len(parts) >= 2 && parts[0] == tool {
 			version := parts[1]
 			return version, nil
 		}
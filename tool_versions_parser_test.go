@@ -142,4 +142,28 @@ func testToolVersionsParser(t *testing.T, context spec.G, it spec.S) {
 			Expect(version).To(BeEmpty())
 		})
 	})
+
+	context("ParseRebar3Version", func() {
+		it("parses the rebar version", func() {
+			content := `	erlang 28.1.1
+					rebar 3.25.1
+				`
+
+			err := os.WriteFile(path, []byte(content), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			version, err := parser.ParseRebar3Version(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(Equal("3.25.1"))
+		})
+
+		it("returns empty string when rebar is not specified", func() {
+			err := os.WriteFile(path, []byte("erlang 28.1.1"), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			version, err := parser.ParseRebar3Version(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(BeEmpty())
+		})
+	})
 }